@@ -0,0 +1,16 @@
+package apperror
+
+import "fmt"
+
+// VersionConflictError is returned when a record is updated based on a version
+// that is no longer the current one, i.e. someone else changed it in between
+type VersionConflictError struct {
+	Resource string
+	ID       int64
+	Expected int64
+	Current  int64
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("%s %d was modified: expected version %d, current version is %d", e.Resource, e.ID, e.Expected, e.Current)
+}
@@ -0,0 +1,154 @@
+package apperror
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Kind classifies an error so the web layer can pick the status code
+type Kind int
+
+// error kinds, Internal is the kind of any error that is not an *Error
+const (
+	Internal Kind = iota
+	NotFoundKind
+	ConflictKind
+	ValidationKind
+	ForbiddenKind
+	UnauthenticatedKind
+)
+
+// FieldError tells why the value of one field is invalid, Code is a stable
+// identifier of the failed rule that clients can rely on, e.g. required.
+// Key and Params are used to translate the message.
+type FieldError struct {
+	Field   string        `json:"field"`
+	Code    string        `json:"code"`
+	Message string        `json:"message"`
+	Key     string        `json:"-"`
+	Params  []interface{} `json:"-"`
+}
+
+// NewFieldError returns the error of a field, the format receives the field
+// followed by the params, e.g. "%s is too long (maximum is %d characters)"
+func NewFieldError(field string, code string, key string, format string, params ...interface{}) *FieldError {
+	return &FieldError{
+		Field:   field,
+		Code:    code,
+		Message: fmt.Sprintf(format, append([]interface{}{field}, params...)...),
+		Key:     key,
+		Params:  params,
+	}
+}
+
+// Error is an error of the domain, Err is the cause when there is one and
+// Fields the invalid fields of a validation error. Errors with a Key can be
+// translated, Args are the arguments of the message.
+type Error struct {
+	Kind    Kind
+	Message string
+	Err     error
+	Fields  []*FieldError
+	Key     string
+	Args    []interface{}
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" && e.Err != nil {
+		return e.Err.Error()
+	}
+
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// NotFound returns an error telling the record does not exist
+func NotFound(format string, args ...interface{}) error {
+	return &Error{Kind: NotFoundKind, Message: fmt.Sprintf(format, args...)}
+}
+
+// Conflict returns an error telling the change conflicts with the current state
+func Conflict(format string, args ...interface{}) error {
+	return &Error{Kind: ConflictKind, Message: fmt.Sprintf(format, args...)}
+}
+
+// New returns an error whose message can be translated through its key
+func New(kind Kind, key string, format string, args ...interface{}) error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...), Key: key, Args: args}
+}
+
+// Validation returns an error telling the input is invalid
+func Validation(format string, args ...interface{}) error {
+	return &Error{Kind: ValidationKind, Message: fmt.Sprintf(format, args...)}
+}
+
+// InvalidField returns a validation error of one field, the code is the key
+// of the message and the format receives the field followed by the params
+func InvalidField(field string, code string, format string, params ...interface{}) error {
+	return InvalidFields([]*FieldError{NewFieldError(field, code, code, format, params...)})
+}
+
+// InvalidFields returns a validation error of several fields, the message
+// joins the messages of the fields
+func InvalidFields(fields []*FieldError) error {
+	msgs := make([]string, len(fields))
+	for i, f := range fields {
+		msgs[i] = f.Message
+	}
+
+	return &Error{Kind: ValidationKind, Message: strings.Join(msgs, "; "), Fields: fields}
+}
+
+// FieldsOf returns the invalid fields of an error, if any
+func FieldsOf(err error) []*FieldError {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Fields
+	}
+
+	return nil
+}
+
+// Forbidden returns an error telling the caller is not allowed to do it
+func Forbidden(format string, args ...interface{}) error {
+	return &Error{Kind: ForbiddenKind, Message: fmt.Sprintf(format, args...)}
+}
+
+// Unauthenticated returns an error telling the caller is not who they claim to be
+func Unauthenticated(format string, args ...interface{}) error {
+	return &Error{Kind: UnauthenticatedKind, Message: fmt.Sprintf(format, args...)}
+}
+
+// Wrap gives a kind to an error keeping its message, nil stays nil
+func Wrap(kind Kind, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &Error{Kind: kind, Err: err}
+}
+
+// KindOf returns the kind of an error or of the first error it wraps that has
+// one. Version conflicts are conflicts and missing rows are not found.
+func KindOf(err error) Kind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+
+	var conflict *VersionConflictError
+	if errors.As(err, &conflict) {
+		return ConflictKind
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return NotFoundKind
+	}
+
+	return Internal
+}
@@ -0,0 +1,67 @@
+package apperror
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// MySQL error numbers translated by FromDB
+const (
+	mysqlDuplicateEntry   = 1062
+	mysqlRowIsReferenced  = 1451
+	mysqlNoReferencedRow  = 1452
+	mysqlRowIsReferenced2 = 1217
+	mysqlNoReferencedRow2 = 1216
+)
+
+var (
+	duplicateKeyRegex = regexp.MustCompile("for key '(?:[^'.]*\\.)?([^']*)'")
+	foreignKeyRegex   = regexp.MustCompile("FOREIGN KEY \\(`([^`]*)`\\)")
+)
+
+// FromDB translates the errors of the database into errors of the domain:
+// missing rows are not found, duplicate keys are conflicts and references to
+// missing records are validation errors. Other errors are returned as they are.
+// The cause is kept, so errors.Is(err, sql.ErrNoRows) still works.
+func FromDB(err error, resource string) error {
+	if err == nil {
+		return nil
+	}
+
+	var e *Error
+	if errors.As(err, &e) {
+		return err
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return &Error{Kind: NotFoundKind, Message: resource + " not found", Err: err}
+	}
+
+	var me *mysql.MySQLError
+	if !errors.As(err, &me) {
+		return err
+	}
+
+	switch me.Number {
+	case mysqlDuplicateEntry:
+		msg := resource + " already exists"
+		if m := duplicateKeyRegex.FindStringSubmatch(me.Message); m != nil && m[1] != "PRIMARY" {
+			msg = fmt.Sprintf("%s with this %s already exists", resource, m[1])
+		}
+		return &Error{Kind: ConflictKind, Message: msg, Err: err}
+	case mysqlNoReferencedRow, mysqlNoReferencedRow2:
+		msg := resource + " references a record that does not exist"
+		if m := foreignKeyRegex.FindStringSubmatch(me.Message); m != nil {
+			msg = fmt.Sprintf("invalid %s: the record does not exist", m[1])
+		}
+		return &Error{Kind: ValidationKind, Message: msg, Err: err}
+	case mysqlRowIsReferenced, mysqlRowIsReferenced2:
+		return &Error{Kind: ConflictKind, Message: resource + " is still referenced by other records", Err: err}
+	}
+
+	return err
+}
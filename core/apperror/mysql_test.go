@@ -0,0 +1,48 @@
+package apperror_test
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/cristiano-pacheco/go-api/core/apperror"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromDB(t *testing.T) {
+	t.Run("TestFromDB nil", func(t *testing.T) {
+		assert.Nil(t, apperror.FromDB(nil, "user"))
+	})
+	t.Run("TestFromDB registro não encontrado", func(t *testing.T) {
+		err := apperror.FromDB(sql.ErrNoRows, "user")
+		assert.Equal(t, apperror.NotFoundKind, apperror.KindOf(err))
+		assert.Equal(t, "user not found", err.Error())
+		assert.True(t, errors.Is(err, sql.ErrNoRows))
+	})
+	t.Run("TestFromDB chave duplicada", func(t *testing.T) {
+		err := apperror.FromDB(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'a@b.com' for key 'user.email'"}, "user")
+		assert.Equal(t, apperror.ConflictKind, apperror.KindOf(err))
+		assert.Equal(t, "user with this email already exists", err.Error())
+	})
+	t.Run("TestFromDB chave estrangeira", func(t *testing.T) {
+		err := apperror.FromDB(&mysql.MySQLError{
+			Number:  1452,
+			Message: "Cannot add or update a child row: a foreign key constraint fails (`go_api`.`list_item`, CONSTRAINT `LIST_ITEM_CATEGORY_ID_CATEGORY_ID` FOREIGN KEY (`category_id`) REFERENCES `category` (`id`) ON DELETE CASCADE)",
+		}, "list item")
+		assert.Equal(t, apperror.ValidationKind, apperror.KindOf(err))
+		assert.Equal(t, "invalid category_id: the record does not exist", err.Error())
+	})
+	t.Run("TestFromDB outros erros", func(t *testing.T) {
+		err := fmt.Errorf("connection refused")
+		assert.Equal(t, err, apperror.FromDB(err, "user"))
+		assert.Equal(t, apperror.Internal, apperror.KindOf(err))
+	})
+}
+
+func TestKindOf(t *testing.T) {
+	assert.Equal(t, apperror.ValidationKind, apperror.KindOf(fmt.Errorf("wrapped: %w", apperror.Validation("name cannot be empty"))))
+	assert.Equal(t, apperror.ConflictKind, apperror.KindOf(&apperror.VersionConflictError{}))
+	assert.Equal(t, apperror.ForbiddenKind, apperror.KindOf(apperror.Wrap(apperror.ForbiddenKind, errors.New("no"))))
+}
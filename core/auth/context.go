@@ -0,0 +1,18 @@
+package auth
+
+import "context"
+
+type contextKey string
+
+const userIDKey contextKey = "UserID"
+
+// ContextWithUserID returns a copy of ctx carrying the authenticated user
+func ContextWithUserID(ctx context.Context, userID int64) context.Context {
+	return context.WithValue(ctx, userIDKey, userID)
+}
+
+// UserIDFromContext returns the authenticated user stored in ctx, if any
+func UserIDFromContext(ctx context.Context) (int64, bool) {
+	userID, ok := ctx.Value(userIDKey).(int64)
+	return userID, ok
+}
@@ -21,6 +21,45 @@ const (
 	UpdateListItemAction  string = "update_list_item"
 	RemoveListItemAction  string = "remove_list_item"
 	UserME                string = "user_me"
+
+	GetAllRecurrencesAction    string = "get_all_recurrences"
+	GetRecurrenceAction        string = "get_recurrence"
+	StoreRecurrenceAction      string = "store_recurrence"
+	UpdateRecurrenceAction     string = "update_recurrence"
+	RemoveRecurrenceAction     string = "remove_recurrence"
+	GetAllRecurrenceRunsAction string = "get_all_recurrence_runs"
+
+	GetAllReminderDeliveriesAction string = "get_all_reminder_deliveries"
+
+	GetAllTagsAction         string = "get_all_tags"
+	UpdateListItemTagsAction string = "update_list_item_tags"
+	GetAllItemsByTagsAction  string = "get_all_items_by_tags"
+
+	GetAllListStatsAction string = "get_all_list_stats"
+	GetListStatsAction    string = "get_list_stats"
+
+	GetListEventsAction string = "get_list_events"
+
+	GetListHistoryAction      string = "get_list_history"
+	RestoreListRevisionAction string = "restore_list_revision"
+
+	GetUserTrashAction     string = "get_user_trash"
+	RestoreUserAction      string = "restore_user"
+	GetListTrashAction     string = "get_list_trash"
+	RestoreListAction      string = "restore_list"
+	GetListItemTrashAction string = "get_list_item_trash"
+	RestoreListItemAction  string = "restore_list_item"
+
+	GetSyncChangesAction     string = "get_sync_changes"
+	StoreSyncMutationsAction string = "store_sync_mutations"
+
+	BatchListItemsAction string = "batch_list_items"
+
+	PatchUserAction     string = "patch_user"
+	PatchListAction     string = "patch_list"
+	PatchListItemAction string = "patch_list_item"
+
+	SearchAction string = "search"
 )
 
 // Token struct
@@ -32,6 +71,8 @@ type Token struct {
 type CustomPayload struct {
 	jwt.Payload
 	UserID int64 `json:"user_id"`
+	// Locale is the preferred locale of the user when the token was issued
+	Locale string `json:"locale,omitempty"`
 }
 
 // UserPermission
@@ -3,11 +3,12 @@ package auth
 import (
 	"database/sql"
 	"errors"
-	"fmt"
 	"time"
 
+	"github.com/cristiano-pacheco/go-api/core/apperror"
 	"github.com/cristiano-pacheco/go-api/core/user"
 	"github.com/gbrlsnchs/jwt/v3"
+	"github.com/prometheus/client_golang/prometheus"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -23,8 +24,21 @@ type Service struct {
 	DB        *sql.DB
 	validator *Validator
 	JWTHash   *jwt.HMACSHA
+	// Failures counts the failed authentications by reason and
+	// PermissionChecks the permission checks by action and result, metrics
+	// are not recorded while they are nil
+	Failures         *prometheus.CounterVec
+	PermissionChecks *prometheus.CounterVec
 }
 
+// reasons of the failed authentications
+const (
+	FailureInvalidCredentials = "invalid_credentials"
+	FailureMissingToken       = "missing_token"
+	FailureInvalidToken       = "invalid_token"
+	FailureForbidden          = "forbidden"
+)
+
 // NewService constructor
 func NewService(db *sql.DB, v *Validator, jwtHash *jwt.HMACSHA) *Service {
 	return &Service{
@@ -36,7 +50,7 @@ func NewService(db *sql.DB, v *Validator, jwtHash *jwt.HMACSHA) *Service {
 
 // GetUserPermissionsById
 func (s *Service) GetUserPermissionsById(ID int) (*UserPermission, error) {
-	stmt, err := s.DB.Prepare("select name from user where id = ?")
+	stmt, err := s.DB.Prepare("select name from user where id = ? and deleted_at is null")
 	if err != nil {
 		return nil, err
 	}
@@ -90,7 +104,7 @@ func (s *Service) GetUserPermissionsById(ID int) (*UserPermission, error) {
 // HasAccess action
 func (s *Service) HasAccess(userID int, action string) (bool, error) {
 	stmt, err := s.DB.Prepare(
-		"select count(1) from user_permission up join permission p on up.permission_id = p.id and p.action = ? where up.user_id = ?",
+		"select count(1) from user_permission up join permission p on up.permission_id = p.id and p.action = ? join user u on up.user_id = u.id and u.deleted_at is null where up.user_id = ?",
 	)
 	if err != nil {
 		return false, err
@@ -103,15 +117,33 @@ func (s *Service) HasAccess(userID int, action string) (bool, error) {
 		return false, err
 	}
 	if hasAccess == 1 {
+		s.countPermissionCheck(action, "allowed")
 		return true, nil
 	}
+	s.countPermissionCheck(action, "denied")
 	return false, nil
 }
 
+// CountFailure records a failed authentication with one of the Failure reasons
+func (s *Service) CountFailure(reason string) {
+	if s.Failures != nil {
+		s.Failures.WithLabelValues(reason).Inc()
+	}
+}
+
+func (s *Service) countPermissionCheck(action, result string) {
+	if s.PermissionChecks != nil {
+		s.PermissionChecks.WithLabelValues(action, result).Inc()
+	}
+}
+
 // IssueToken a new token
 func (s *Service) IssueToken(email, password string) (*Token, error) {
 	u, err := s.checkUserCredentials(email, password)
 	if err != nil {
+		if apperror.KindOf(err) == apperror.UnauthenticatedKind {
+			s.CountFailure(FailureInvalidCredentials)
+		}
 		return nil, err
 	}
 
@@ -123,6 +155,7 @@ func (s *Service) IssueToken(email, password string) (*Token, error) {
 			IssuedAt:       jwt.NumericDate(now),
 		},
 		UserID: u.ID,
+		Locale: u.Locale,
 	}
 
 	token, err := jwt.Sign(pl, s.JWTHash)
@@ -143,15 +176,15 @@ func (s *Service) checkUserCredentials(email, password string) (*user.User, erro
 
 	var u user.User
 
-	stmt, err := s.DB.Prepare("select id, name, email, password, is_active, is_admin from user where email = ? and is_active = 1")
+	stmt, err := s.DB.Prepare("select id, name, email, password, locale, is_active, is_admin from user where email = ? and is_active = 1 and deleted_at is null")
 	if err != nil {
 		return nil, err
 	}
 
-	err = stmt.QueryRow(email).Scan(&u.ID, &u.Name, &u.Email, &u.Password, &u.IsActive, &u.IsAdmin)
+	err = stmt.QueryRow(email).Scan(&u.ID, &u.Name, &u.Email, &u.Password, &u.Locale, &u.IsActive, &u.IsAdmin)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, fmt.Errorf("Invalid Credentials")
+			return nil, apperror.New(apperror.UnauthenticatedKind, "invalid_credentials", "Invalid Credentials")
 		}
 		return nil, err
 	}
@@ -165,7 +198,7 @@ func (s *Service) checkUserCredentials(email, password string) (*user.User, erro
 	err = bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password))
 	if err != nil {
 		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
-			return nil, fmt.Errorf("Invalid Credentials")
+			return nil, apperror.New(apperror.UnauthenticatedKind, "invalid_credentials", "Invalid Credentials")
 		}
 		return nil, err
 	}
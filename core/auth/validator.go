@@ -8,20 +8,12 @@ import (
 type Validator struct{}
 
 func (uv *Validator) validate(email, password string) error {
-	err := validator.NotEmpty("email", email)
-	if err != nil {
-		return err
+	var errs validator.Errors
+	errs.Add(validator.NotEmpty("email", email))
+	if !errs.Has("email") {
+		errs.Add(validator.Email("email", email))
 	}
+	errs.Add(validator.NotEmpty("password", password))
 
-	err = validator.Email("email", email)
-	if err != nil {
-		return err
-	}
-
-	err = validator.NotEmpty("password", password)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return errs.Err()
 }
@@ -25,10 +25,15 @@ DROP TABLE IF EXISTS `list`;
 CREATE TABLE `list` (
   `id` int(11) NOT NULL AUTO_INCREMENT,
   `name` varchar(255) NOT NULL,
+  `notes` varchar(2000) NOT NULL DEFAULT '',
   `is_active` tinyint(1) NOT NULL DEFAULT '1',
+  `version` int(11) NOT NULL DEFAULT '1',
   `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
   `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
-  PRIMARY KEY (`id`)
+  `deleted_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `list_deleted_at` (`deleted_at`),
+  FULLTEXT KEY `list_search` (`name`, `notes`)
 ) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8;
 /*!40101 SET character_set_client = @saved_cs_client */;
 
@@ -60,16 +65,171 @@ DROP TABLE IF EXISTS `list_item`;
 CREATE TABLE `list_item` (
   `id` int(11) NOT NULL AUTO_INCREMENT,
   `list_id` int(11) NOT NULL,
+  `parent_id` int(11) DEFAULT NULL,
   `category_id` int(11) NOT NULL,
   `name` varchar(255) NOT NULL,
+  `notes` varchar(2000) NOT NULL DEFAULT '',
+  `is_checked` tinyint(1) NOT NULL DEFAULT '0',
+  `price` decimal(10,2) DEFAULT NULL,
+  `due_at` datetime DEFAULT NULL,
+  `remind_at` datetime DEFAULT NULL,
+  `version` int(11) NOT NULL DEFAULT '1',
   `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
   `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  `deleted_at` datetime DEFAULT NULL,
   PRIMARY KEY (`id`),
+  KEY `list_item_remind_at` (`remind_at`),
+  KEY `list_item_deleted_at` (`deleted_at`),
+  FULLTEXT KEY `list_item_search` (`name`, `notes`),
   CONSTRAINT `LIST_ITEM_LIST_ID_LIST_ID` FOREIGN KEY (`list_id`) REFERENCES `list` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `LIST_ITEM_PARENT_ID_LIST_ITEM_ID` FOREIGN KEY (`parent_id`) REFERENCES `list_item` (`id`) ON DELETE CASCADE,
   CONSTRAINT `LIST_ITEM_CATEGORY_ID_CATEGORY_ID` FOREIGN KEY (`category_id`) REFERENCES `category` (`id`) ON DELETE CASCADE
 ) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8;
 /*!40101 SET character_set_client = @saved_cs_client */;
 
+--
+-- Table structure for table `recurrence`
+--
+
+DROP TABLE IF EXISTS `recurrence`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!40101 SET character_set_client = utf8 */;
+CREATE TABLE `recurrence` (
+  `id` int(11) NOT NULL AUTO_INCREMENT,
+  `list_id` int(11) NOT NULL,
+  `rule` varchar(255) NOT NULL,
+  `starts_at` datetime NOT NULL,
+  `next_run_at` datetime NOT NULL,
+  `last_run_at` datetime DEFAULT NULL,
+  `is_active` tinyint(1) NOT NULL DEFAULT '1',
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `recurrence_next_run_at` (`is_active`,`next_run_at`),
+  CONSTRAINT `RECURRENCE_LIST_ID_LIST_ID` FOREIGN KEY (`list_id`) REFERENCES `list` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Table structure for table `recurrence_run`
+--
+
+DROP TABLE IF EXISTS `recurrence_run`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!40101 SET character_set_client = utf8 */;
+CREATE TABLE `recurrence_run` (
+  `id` int(11) NOT NULL AUTO_INCREMENT,
+  `recurrence_id` int(11) NOT NULL,
+  `scheduled_for` datetime NOT NULL,
+  `list_id` int(11) DEFAULT NULL,
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `recurrence_run_UN` (`recurrence_id`,`scheduled_for`),
+  CONSTRAINT `RECURRENCE_RUN_RECURRENCE_ID_RECURRENCE_ID` FOREIGN KEY (`recurrence_id`) REFERENCES `recurrence` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `RECURRENCE_RUN_LIST_ID_LIST_ID` FOREIGN KEY (`list_id`) REFERENCES `list` (`id`) ON DELETE SET NULL
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Table structure for table `reminder_delivery`
+--
+
+DROP TABLE IF EXISTS `reminder_delivery`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!40101 SET character_set_client = utf8 */;
+CREATE TABLE `reminder_delivery` (
+  `id` int(11) NOT NULL AUTO_INCREMENT,
+  `list_item_id` int(11) NOT NULL,
+  `remind_at` datetime NOT NULL,
+  `notifier` varchar(50) NOT NULL,
+  `status` varchar(10) NOT NULL DEFAULT 'pending',
+  `error` varchar(500) NOT NULL DEFAULT '',
+  `attempts` int(11) NOT NULL DEFAULT 1,
+  `claimed_at` datetime NOT NULL,
+  `delivered_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `reminder_delivery_UN` (`list_item_id`,`remind_at`),
+  CONSTRAINT `REMINDER_DELIVERY_LIST_ITEM_ID_LIST_ITEM_ID` FOREIGN KEY (`list_item_id`) REFERENCES `list_item` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Table structure for table `email_outbox`
+--
+
+DROP TABLE IF EXISTS `email_outbox`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!40101 SET character_set_client = utf8 */;
+CREATE TABLE `email_outbox` (
+  `id` int(11) NOT NULL AUTO_INCREMENT,
+  `recipient` varchar(255) NOT NULL,
+  `subject` varchar(255) NOT NULL,
+  `body` text NOT NULL,
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  `sent_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`)
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Table structure for table `tag`
+--
+
+DROP TABLE IF EXISTS `tag`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!40101 SET character_set_client = utf8 */;
+CREATE TABLE `tag` (
+  `id` int(11) NOT NULL AUTO_INCREMENT,
+  `user_id` int(11) NOT NULL,
+  `name` varchar(50) NOT NULL,
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `tag_UN` (`user_id`,`name`),
+  KEY `tag_name` (`name`),
+  CONSTRAINT `TAG_USER_ID_USER_ID` FOREIGN KEY (`user_id`) REFERENCES `user` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Table structure for table `list_item_tag`
+--
+
+DROP TABLE IF EXISTS `list_item_tag`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!40101 SET character_set_client = utf8 */;
+CREATE TABLE `list_item_tag` (
+  `list_item_id` int(11) NOT NULL,
+  `tag_id` int(11) NOT NULL,
+  PRIMARY KEY (`list_item_id`,`tag_id`),
+  KEY `list_item_tag_FK_1` (`tag_id`),
+  CONSTRAINT `LIST_ITEM_TAG_LIST_ITEM_ID_LIST_ITEM_ID` FOREIGN KEY (`list_item_id`) REFERENCES `list_item` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `LIST_ITEM_TAG_TAG_ID_TAG_ID` FOREIGN KEY (`tag_id`) REFERENCES `tag` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Table structure for table `revision`
+--
+
+DROP TABLE IF EXISTS `revision`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!40101 SET character_set_client = utf8 */;
+CREATE TABLE `revision` (
+  `id` int(11) NOT NULL AUTO_INCREMENT,
+  `entity_type` varchar(20) NOT NULL,
+  `entity_id` int(11) NOT NULL,
+  `list_id` int(11) NOT NULL,
+  `action` varchar(20) NOT NULL,
+  `actor_id` int(11) DEFAULT NULL,
+  `before_data` json DEFAULT NULL,
+  `after_data` json DEFAULT NULL,
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `revision_list_id` (`list_id`),
+  CONSTRAINT `REVISION_ACTOR_ID_USER_ID` FOREIGN KEY (`actor_id`) REFERENCES `user` (`id`) ON DELETE SET NULL
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
 --
 -- Table structure for table `permission`
 --
@@ -84,7 +244,7 @@ CREATE TABLE `permission` (
   `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
   `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
   PRIMARY KEY (`id`)
-) ENGINE=InnoDB AUTO_INCREMENT=6 DEFAULT CHARSET=utf8;
+) ENGINE=InnoDB AUTO_INCREMENT=34 DEFAULT CHARSET=utf8;
 /*!40101 SET character_set_client = @saved_cs_client */;
 
 --
@@ -93,10 +253,34 @@ CREATE TABLE `permission` (
 
 LOCK TABLES `permission` WRITE;
 /*!40000 ALTER TABLE `permission` DISABLE KEYS */;
-INSERT INTO `permission` VALUES (1,'Get All Users','get_all_users','2021-04-05 22:25:37','2021-04-05 22:27:11'),(2,'Get User','get_user','2021-04-05 22:26:07','2021-04-05 22:27:11'),(3,'Store User','store_user','2021-04-05 22:26:30','2021-04-05 22:27:11'),(4,'Update User','update_user','2021-04-05 22:27:40','2021-04-05 22:27:40'),(5,'Remove User','remove_user','2021-04-05 22:28:00','2021-04-05 22:28:00');
+INSERT INTO `permission` VALUES (1,'Get All Users','get_all_users','2021-04-05 22:25:37','2021-04-05 22:27:11'),(2,'Get User','get_user','2021-04-05 22:26:07','2021-04-05 22:27:11'),(3,'Store User','store_user','2021-04-05 22:26:30','2021-04-05 22:27:11'),(4,'Update User','update_user','2021-04-05 22:27:40','2021-04-05 22:27:40'),(5,'Remove User','remove_user','2021-04-05 22:28:00','2021-04-05 22:28:00'),(6,'Get All Recurrences','get_all_recurrences','2021-04-05 22:28:00','2021-04-05 22:28:00'),(7,'Get Recurrence','get_recurrence','2021-04-05 22:28:00','2021-04-05 22:28:00'),(8,'Store Recurrence','store_recurrence','2021-04-05 22:28:00','2021-04-05 22:28:00'),(9,'Update Recurrence','update_recurrence','2021-04-05 22:28:00','2021-04-05 22:28:00'),(10,'Remove Recurrence','remove_recurrence','2021-04-05 22:28:00','2021-04-05 22:28:00'),(11,'Get All Recurrence Runs','get_all_recurrence_runs','2021-04-05 22:28:00','2021-04-05 22:28:00'),(12,'Get All Reminder Deliveries','get_all_reminder_deliveries','2021-04-05 22:28:00','2021-04-05 22:28:00'),(13,'Get All Tags','get_all_tags','2021-04-05 22:28:00','2021-04-05 22:28:00'),(14,'Update List Item Tags','update_list_item_tags','2021-04-05 22:28:00','2021-04-05 22:28:00'),(15,'Get All Items By Tags','get_all_items_by_tags','2021-04-05 22:28:00','2021-04-05 22:28:00'),(16,'Get All List Stats','get_all_list_stats','2021-04-05 22:28:00','2021-04-05 22:28:00'),(17,'Get List Stats','get_list_stats','2021-04-05 22:28:00','2021-04-05 22:28:00'),(18,'Get List Events','get_list_events','2021-04-05 22:28:00','2021-04-05 22:28:00'),(19,'Get List History','get_list_history','2021-04-05 22:28:00','2021-04-05 22:28:00'),(20,'Restore List Revision','restore_list_revision','2021-04-05 22:28:00','2021-04-05 22:28:00'),(21,'Get User Trash','get_user_trash','2021-04-05 22:28:00','2021-04-05 22:28:00'),(22,'Restore User','restore_user','2021-04-05 22:28:00','2021-04-05 22:28:00'),(23,'Get List Trash','get_list_trash','2021-04-05 22:28:00','2021-04-05 22:28:00'),(24,'Restore List','restore_list','2021-04-05 22:28:00','2021-04-05 22:28:00'),(25,'Get List Item Trash','get_list_item_trash','2021-04-05 22:28:00','2021-04-05 22:28:00'),(26,'Restore List Item','restore_list_item','2021-04-05 22:28:00','2021-04-05 22:28:00'),(27,'Get Sync Changes','get_sync_changes','2021-04-05 22:28:00','2021-04-05 22:28:00'),(28,'Store Sync Mutations','store_sync_mutations','2021-04-05 22:28:00','2021-04-05 22:28:00'),(29,'Batch List Items','batch_list_items','2021-04-05 22:28:00','2021-04-05 22:28:00'),(30,'Patch User','patch_user','2021-04-05 22:28:00','2021-04-05 22:28:00'),(31,'Patch List','patch_list','2021-04-05 22:28:00','2021-04-05 22:28:00'),(32,'Patch List Item','patch_list_item','2021-04-05 22:28:00','2021-04-05 22:28:00'),(33,'Search','search','2021-04-05 22:28:00','2021-04-05 22:28:00');
 /*!40000 ALTER TABLE `permission` ENABLE KEYS */;
 UNLOCK TABLES;
 
+--
+-- Table structure for table `schema_version`
+--
+
+DROP TABLE IF EXISTS `schema_version`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!40101 SET character_set_client = utf8 */;
+CREATE TABLE `schema_version` (
+  `version` int(11) NOT NULL,
+  `applied_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`version`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Dumping data for table `schema_version`
+--
+
+LOCK TABLES `schema_version` WRITE;
+/*!40000 ALTER TABLE `schema_version` DISABLE KEYS */;
+INSERT INTO `schema_version` VALUES (1,'2021-04-05 22:28:00');
+/*!40000 ALTER TABLE `schema_version` ENABLE KEYS */;
+UNLOCK TABLES;
+
 --
 -- Table structure for table `user`
 --
@@ -109,12 +293,17 @@ CREATE TABLE `user` (
   `name` varchar(255) NOT NULL,
   `email` varchar(255) NOT NULL,
   `password` char(60) NOT NULL,
+  `locale` varchar(10) NOT NULL DEFAULT '',
   `is_active` tinyint(1) NOT NULL DEFAULT '1',
   `is_admin` tinyint(1) NOT NULL DEFAULT '0',
+  `version` int(11) NOT NULL DEFAULT '1',
   `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
   `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  `deleted_at` datetime DEFAULT NULL,
+  `active_email` varchar(255) GENERATED ALWAYS AS (if(`deleted_at` is null, `email`, null)) VIRTUAL,
   PRIMARY KEY (`id`),
-  UNIQUE KEY `user_uc_email` (`email`)
+  UNIQUE KEY `user_uc_email` (`active_email`),
+  KEY `user_deleted_at` (`deleted_at`)
 ) ENGINE=InnoDB AUTO_INCREMENT=22 DEFAULT CHARSET=utf8;
 /*!40101 SET character_set_client = @saved_cs_client */;
 
@@ -124,7 +313,7 @@ CREATE TABLE `user` (
 
 LOCK TABLES `user` WRITE;
 /*!40000 ALTER TABLE `user` DISABLE KEYS */;
-INSERT INTO `user` VALUES (1,'Cristiano Pacheco','chris.spb25@gmail.com','$2a$12$5z2tVJvfymdH6odt20FemerzuVGhjFQ1fPRk4LqCo2tYC2kEv0Pqi',1,1,'2021-04-04 17:48:21','2021-04-05 22:30:23');
+INSERT INTO `user` (`id`, `name`, `email`, `password`, `is_active`, `is_admin`, `version`, `created_at`, `updated_at`, `deleted_at`) VALUES (1,'Cristiano Pacheco','chris.spb25@gmail.com','$2a$12$5z2tVJvfymdH6odt20FemerzuVGhjFQ1fPRk4LqCo2tYC2kEv0Pqi',1,1,1,'2021-04-04 17:48:21','2021-04-05 22:30:23',NULL);
 /*!40000 ALTER TABLE `user` ENABLE KEYS */;
 UNLOCK TABLES;
 
@@ -151,7 +340,7 @@ CREATE TABLE `user_permission` (
 
 LOCK TABLES `user_permission` WRITE;
 /*!40000 ALTER TABLE `user_permission` DISABLE KEYS */;
-INSERT INTO `user_permission` VALUES (1,1),(1,2),(1,3),(1,4),(1,5);
+INSERT INTO `user_permission` VALUES (1,1),(1,2),(1,3),(1,4),(1,5),(1,6),(1,7),(1,8),(1,9),(1,10),(1,11),(1,12),(1,13),(1,14),(1,15),(1,16),(1,17),(1,18),(1,19),(1,20),(1,21),(1,22),(1,23),(1,24),(1,25),(1,26),(1,27),(1,28),(1,29),(1,30),(1,31),(1,32),(1,33);
 /*!40000 ALTER TABLE `user_permission` ENABLE KEYS */;
 UNLOCK TABLES;
 
@@ -0,0 +1,151 @@
+package event
+
+import (
+	"sync"
+	"time"
+)
+
+// subscriptionBuffer is the number of events a subscriber may fall behind
+// before it is disconnected
+const subscriptionBuffer = 64
+
+// Broker is an in-process publish/subscribe hub. It keeps the last events in
+// memory so subscribers can resume from the last event they received.
+type Broker struct {
+	mu          sync.Mutex
+	lastID      int64
+	history     []*Event
+	historySize int
+	subscribers map[*Subscription]struct{}
+	closed      bool
+}
+
+// Subscription receives the events of one list, or of all lists when ListID is 0.
+// C is closed when the subscription ends, either by Unsubscribe, by the broker
+// shutting down or because the subscriber was too slow to keep up.
+type Subscription struct {
+	ListID int64
+	C      chan *Event
+	broker *Broker
+}
+
+// NewBroker constructor, historySize is the number of events kept for resuming
+func NewBroker(historySize int) *Broker {
+	return &Broker{
+		historySize: historySize,
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish assigns the next ID to the event and sends it to the subscribers
+func (b *Broker) Publish(e *Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+
+	b.lastID++
+	e.ID = b.lastID
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now().UTC()
+	}
+
+	b.history = append(b.history, e)
+	if len(b.history) > b.historySize {
+		b.history = b.history[len(b.history)-b.historySize:]
+	}
+
+	for sub := range b.subscribers {
+		if sub.ListID != 0 && sub.ListID != e.ListID {
+			continue
+		}
+
+		select {
+		case sub.C <- e:
+		default:
+			b.remove(sub)
+		}
+	}
+}
+
+// Subscribe to the events of a list. When lastEventID is not 0 the events
+// published after it are returned to be replayed; complete is false when some
+// of them are no longer available, e.g. after a restart, and the subscriber
+// must reload the list instead.
+func (b *Broker) Subscribe(listID, lastEventID int64) (sub *Subscription, replay []*Event, complete bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub = &Subscription{
+		ListID: listID,
+		C:      make(chan *Event, subscriptionBuffer),
+		broker: b,
+	}
+
+	if b.closed {
+		close(sub.C)
+		return sub, nil, true
+	}
+
+	b.subscribers[sub] = struct{}{}
+
+	if lastEventID == 0 {
+		return sub, nil, true
+	}
+
+	complete = lastEventID <= b.lastID
+	if len(b.history) > 0 && b.history[0].ID > lastEventID+1 {
+		complete = false
+	}
+
+	for _, e := range b.history {
+		if e.ID > lastEventID && (listID == 0 || e.ListID == listID) {
+			replay = append(replay, e)
+		}
+	}
+
+	return sub, replay, complete
+}
+
+// LastID returns the ID of the last published event
+func (b *Broker) LastID() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.lastID
+}
+
+// Unsubscribe ends the subscription
+func (s *Subscription) Unsubscribe() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.broker.remove(s)
+}
+
+// Close ends all subscriptions and stops accepting events
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for sub := range b.subscribers {
+		b.remove(sub)
+	}
+}
+
+// Closed tells if Close was called
+func (b *Broker) Closed() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.closed
+}
+
+// remove must be called with the lock held
+func (b *Broker) remove(sub *Subscription) {
+	if _, ok := b.subscribers[sub]; !ok {
+		return
+	}
+	delete(b.subscribers, sub)
+	close(sub.C)
+}
@@ -0,0 +1,68 @@
+package event_test
+
+import (
+	"testing"
+
+	"github.com/cristiano-pacheco/go-api/core/event"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublish(t *testing.T) {
+	b := event.NewBroker(10)
+	sub, replay, complete := b.Subscribe(1, 0)
+	all, _, _ := b.Subscribe(0, 0)
+	assert.Nil(t, replay)
+	assert.True(t, complete)
+
+	b.Publish(&event.Event{Type: event.ItemCreated, ListID: 2})
+	b.Publish(&event.Event{Type: event.ItemCreated, ListID: 1})
+
+	e := <-sub.C
+	assert.Equal(t, int64(2), e.ID)
+	assert.Equal(t, int64(1), e.ListID)
+	assert.Equal(t, 0, len(sub.C))
+	assert.Equal(t, 2, len(all.C))
+
+	sub.Unsubscribe()
+	_, ok := <-sub.C
+	assert.False(t, ok)
+}
+
+func TestSubscribeResume(t *testing.T) {
+	b := event.NewBroker(3)
+	for i := 0; i < 5; i++ {
+		b.Publish(&event.Event{Type: event.ItemUpdated, ListID: 1})
+	}
+
+	_, replay, complete := b.Subscribe(1, 3)
+	assert.True(t, complete)
+	assert.Equal(t, 2, len(replay))
+	assert.Equal(t, int64(4), replay[0].ID)
+
+	// event 2 was evicted from the history
+	_, replay, complete = b.Subscribe(1, 1)
+	assert.False(t, complete)
+	assert.Equal(t, 3, len(replay))
+
+	// the broker restarted and never published event 10
+	_, _, complete = b.Subscribe(1, 10)
+	assert.False(t, complete)
+}
+
+func TestSlowSubscriberAndClose(t *testing.T) {
+	b := event.NewBroker(100)
+	slow, _, _ := b.Subscribe(1, 0)
+	for i := 0; i < 65; i++ {
+		b.Publish(&event.Event{Type: event.ItemUpdated, ListID: 1})
+	}
+	count := 0
+	for range slow.C {
+		count++
+	}
+	assert.Equal(t, 64, count)
+
+	sub, _, _ := b.Subscribe(1, 0)
+	b.Close()
+	_, ok := <-sub.C
+	assert.False(t, ok)
+}
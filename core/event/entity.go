@@ -0,0 +1,30 @@
+package event
+
+import "time"
+
+const (
+	ListCreated     string = "list.created"
+	ListUpdated     string = "list.updated"
+	ListRemoved     string = "list.removed"
+	ItemCreated     string = "item.created"
+	ItemUpdated     string = "item.updated"
+	ItemRemoved     string = "item.removed"
+	ItemTagsUpdated string = "item.tags_updated"
+
+	// Reset tells a resuming subscriber that events were lost and it must reload the list
+	Reset string = "reset"
+)
+
+// Event describes a change made to a list or to one of its items
+type Event struct {
+	ID        int64       `json:"id"`
+	Type      string      `json:"type"`
+	ListID    int64       `json:"list_id"`
+	Data      interface{} `json:"data"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// Publisher receives the events of the services
+type Publisher interface {
+	Publish(e *Event)
+}
@@ -0,0 +1,170 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SchemaVersion is the version of core/db_ddl.sql the code expects, it must be
+// bumped with every change to the schema and the row of schema_version
+const SchemaVersion = 1
+
+// statuses of the checks and of the report
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// Check returns an error when a dependency is not usable. The context is
+// canceled when the check takes longer than the timeout of the checker.
+type Check func(ctx context.Context) error
+
+// Result is the outcome of a check
+type Result struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report is the outcome of every check, its status is ok when all of them are
+type Report struct {
+	Status string    `json:"status"`
+	Checks []*Result `json:"checks"`
+}
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Checker runs the checks that tell if the process is ready to serve requests
+type Checker struct {
+	Timeout  time.Duration
+	checks   []namedCheck
+	draining int32
+}
+
+// NewChecker constructor
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{Timeout: timeout}
+}
+
+// Add adds a check, checks are reported in the order they were added
+func (c *Checker) Add(name string, check Check) {
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// Drain makes every report fail from now on, it is called when the process
+// starts shutting down so the load balancer stops sending it requests
+func (c *Checker) Drain() {
+	atomic.StoreInt32(&c.draining, 1)
+}
+
+// Run runs every check at the same time and waits for all of them. Once the
+// checker is draining the checks are not run anymore.
+func (c *Checker) Run(ctx context.Context) *Report {
+	if atomic.LoadInt32(&c.draining) == 1 {
+		return &Report{Status: StatusFail, Checks: []*Result{
+			{Name: "shutdown", Status: StatusFail, Error: "the server is shutting down"},
+		}}
+	}
+
+	report := &Report{Status: StatusOK, Checks: make([]*Result, len(c.checks))}
+
+	var wg sync.WaitGroup
+	for i, nc := range c.checks {
+		wg.Add(1)
+		go func(i int, nc namedCheck) {
+			defer wg.Done()
+			report.Checks[i] = c.run(ctx, nc)
+		}(i, nc)
+	}
+	wg.Wait()
+
+	for _, r := range report.Checks {
+		if r.Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, nc namedCheck) *Result {
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := nc.check(ctx)
+	r := &Result{
+		Name:      nc.name,
+		Status:    StatusOK,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		r.Status = StatusFail
+		r.Error = err.Error()
+	}
+
+	return r
+}
+
+// Ping checks the database accepts connections
+func Ping(db *sql.DB) Check {
+	return func(ctx context.Context) error {
+		return db.PingContext(ctx)
+	}
+}
+
+// Schema checks the database has the schema version the code expects
+func Schema(db *sql.DB, expected int) Check {
+	return func(ctx context.Context) error {
+		var version sql.NullInt64
+		err := db.QueryRowContext(ctx, "select max(version) from schema_version").Scan(&version)
+		if err != nil {
+			return err
+		}
+
+		if int(version.Int64) != expected {
+			return fmt.Errorf("schema version is %d, expected %d", version.Int64, expected)
+		}
+
+		return nil
+	}
+}
+
+// Runner is a background job that reports when its runs start and finish,
+// e.g. worker.Worker
+type Runner interface {
+	Started() time.Time
+	Status() (time.Time, error)
+}
+
+// Running checks a run of a background job is not in progress for more than
+// maxAge and that the last one finished within maxAge. The errors of the job
+// are not failures, they are logged by the job and a failing dependency such
+// as a webhook should not take the API out of the load balancer.
+func Running(r Runner, maxAge time.Duration) Check {
+	return func(ctx context.Context) error {
+		started := r.Started()
+		finished, _ := r.Status()
+
+		if started.After(finished) {
+			if time.Since(started) > maxAge {
+				return fmt.Errorf("running since %s", started.Format(time.RFC3339))
+			}
+			return nil
+		}
+
+		if !finished.IsZero() && time.Since(finished) > maxAge {
+			return fmt.Errorf("last run finished at %s", finished.Format(time.RFC3339))
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,96 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cristiano-pacheco/go-api/core/health"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeRunner struct {
+	started time.Time
+	lastRun time.Time
+	err     error
+}
+
+func (f *fakeRunner) Started() time.Time {
+	return f.started
+}
+
+func (f *fakeRunner) Status() (time.Time, error) {
+	return f.lastRun, f.err
+}
+
+func TestChecker(t *testing.T) {
+	t.Run("Teste todos os checks ok", func(t *testing.T) {
+		c := health.NewChecker(time.Second)
+		c.Add("a", func(ctx context.Context) error { return nil })
+		c.Add("b", func(ctx context.Context) error { return nil })
+
+		report := c.Run(context.Background())
+		assert.Equal(t, health.StatusOK, report.Status)
+		assert.Equal(t, "a", report.Checks[0].Name)
+		assert.Equal(t, "b", report.Checks[1].Name)
+	})
+
+	t.Run("Teste check com falha", func(t *testing.T) {
+		c := health.NewChecker(time.Second)
+		c.Add("database", func(ctx context.Context) error { return errors.New("connection refused") })
+		c.Add("worker", func(ctx context.Context) error { return nil })
+
+		report := c.Run(context.Background())
+		assert.Equal(t, health.StatusFail, report.Status)
+		assert.Equal(t, health.StatusFail, report.Checks[0].Status)
+		assert.Equal(t, "connection refused", report.Checks[0].Error)
+		assert.Equal(t, health.StatusOK, report.Checks[1].Status)
+	})
+
+	t.Run("Teste timeout", func(t *testing.T) {
+		c := health.NewChecker(10 * time.Millisecond)
+		c.Add("slow", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+		report := c.Run(context.Background())
+		assert.Equal(t, health.StatusFail, report.Status)
+		assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks[0].Error)
+	})
+
+	t.Run("Teste falha durante o desligamento", func(t *testing.T) {
+		c := health.NewChecker(time.Second)
+		c.Add("database", func(ctx context.Context) error { return nil })
+		assert.Equal(t, health.StatusOK, c.Run(context.Background()).Status)
+
+		c.Drain()
+		report := c.Run(context.Background())
+		assert.Equal(t, health.StatusFail, report.Status)
+		assert.Equal(t, "shutdown", report.Checks[0].Name)
+	})
+
+	t.Run("Teste worker parado", func(t *testing.T) {
+		hourAgo := time.Now().Add(-time.Hour)
+		check := health.Running(&fakeRunner{started: hourAgo, lastRun: hourAgo.Add(time.Second)}, time.Minute)
+		assert.NotNil(t, check(context.Background()))
+
+		check = health.Running(&fakeRunner{started: time.Now(), lastRun: time.Now(), err: errors.New("webhook down")}, time.Minute)
+		assert.Nil(t, check(context.Background()))
+
+		check = health.Running(&fakeRunner{}, time.Minute)
+		assert.Nil(t, check(context.Background()))
+	})
+
+	t.Run("Teste worker travado", func(t *testing.T) {
+		check := health.Running(&fakeRunner{started: time.Now().Add(-time.Hour)}, time.Minute)
+		assert.Contains(t, check(context.Background()).Error(), "running since")
+
+		check = health.Running(&fakeRunner{started: time.Now().Add(-time.Second), lastRun: time.Now().Add(-time.Hour)}, time.Minute)
+		assert.Nil(t, check(context.Background()))
+
+		check = health.Running(&fakeRunner{started: time.Now().Add(-time.Hour), lastRun: time.Now().Add(-2 * time.Hour)}, time.Minute)
+		assert.NotNil(t, check(context.Background()))
+	})
+}
@@ -0,0 +1,48 @@
+package i18n
+
+// catalogs hold the messages of each locale by key. The keys of field errors
+// are the codes of core/validator and their messages receive the field first.
+var catalogs = map[string]map[string]string{
+	En: {
+		"required":           "%s cannot be empty",
+		"email":              "%s is not a valid email",
+		"max_length":         "%s is too long (maximum is %d characters)",
+		"min_length":         "%s is too short (minimum is %d characters)",
+		"min":                "%s cannot be less than %s",
+		"max":                "%s cannot be greater than %s",
+		"oneof":              "%s must be one of %s",
+		"invalid":            "%s is invalid",
+		"after_field":        "%s cannot be after %s",
+		"before_field":       "%s cannot be before %s",
+		"greater_than_field": "%s cannot be greater than %s",
+		"less_than_field":    "%s cannot be less than %s",
+		"equal_field":        "%s cannot be equal to %s",
+		"invalid_rule":       "%s is invalid: %s",
+
+		"invalid_id":          "invalid ID",
+		"invalid_credentials": "Invalid Credentials",
+		"not_authorized":      "Not Authorized",
+		"forbidden":           "You do not have permission to do this",
+	},
+	PtBR: {
+		"required":           "%s não pode ficar vazio",
+		"email":              "%s não é um e-mail válido",
+		"max_length":         "%s é muito longo (máximo de %d caracteres)",
+		"min_length":         "%s é muito curto (mínimo de %d caracteres)",
+		"min":                "%s não pode ser menor que %s",
+		"max":                "%s não pode ser maior que %s",
+		"oneof":              "%s deve ser um destes: %s",
+		"invalid":            "%s é inválido",
+		"after_field":        "%s não pode ser depois de %s",
+		"before_field":       "%s não pode ser antes de %s",
+		"greater_than_field": "%s não pode ser maior que %s",
+		"less_than_field":    "%s não pode ser menor que %s",
+		"equal_field":        "%s não pode ser igual a %s",
+		"invalid_rule":       "%s é inválido: %s",
+
+		"invalid_id":          "ID inválido",
+		"invalid_credentials": "Credenciais inválidas",
+		"not_authorized":      "Não autorizado",
+		"forbidden":           "Você não tem permissão para fazer isso",
+	},
+}
@@ -0,0 +1,119 @@
+package i18n
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// supported locales, messages missing in a catalog fall back to English
+const (
+	En      = "en"
+	PtBR    = "pt-BR"
+	Default = En
+)
+
+// Locales are the supported locales
+var Locales = []string{En, PtBR}
+
+type contextKey struct{}
+
+// WithLocale returns a context carrying the locale of a request
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, contextKey{}, locale)
+}
+
+// FromContext returns the locale of a request, the default one when it has none
+func FromContext(ctx context.Context) string {
+	locale, ok := ctx.Value(contextKey{}).(string)
+	if !ok || locale == "" {
+		return Default
+	}
+
+	return locale
+}
+
+// Message returns the message of key in a locale formatted with args, ok is
+// false when no catalog has the key
+func Message(locale string, key string, args ...interface{}) (string, bool) {
+	format, ok := catalogs[locale][key]
+	if !ok {
+		format, ok = catalogs[Default][key]
+	}
+	if !ok {
+		return "", false
+	}
+
+	return fmt.Sprintf(format, args...), true
+}
+
+// T returns the message of key in the locale of ctx, or the key itself when
+// no catalog has it
+func T(ctx context.Context, key string, args ...interface{}) string {
+	msg, ok := Message(FromContext(ctx), key, args...)
+	if !ok {
+		return key
+	}
+
+	return msg
+}
+
+// Supported returns the supported locale matching a language tag, ignoring
+// case, and false when there is none
+func Supported(tag string) (string, bool) {
+	for _, l := range Locales {
+		if strings.EqualFold(l, tag) {
+			return l, true
+		}
+	}
+
+	return "", false
+}
+
+// Match returns the supported locale that best matches an Accept-Language
+// header, e.g. "pt-BR,pt;q=0.9,en;q=0.8". A tag matches its own locale or,
+// failing that, the first locale of the same language, so pt-PT gets pt-BR.
+// It returns the default locale when nothing matches.
+func Match(acceptLanguage string) string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+
+	var tags []weighted
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		w := weighted{tag: strings.TrimSpace(fields[0]), q: 1}
+		for _, p := range fields[1:] {
+			p = strings.TrimSpace(p)
+			if strings.HasPrefix(p, "q=") {
+				q, err := strconv.ParseFloat(p[2:], 64)
+				if err == nil {
+					w.q = q
+				}
+			}
+		}
+		if w.tag != "" && w.q > 0 {
+			tags = append(tags, w)
+		}
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	for _, w := range tags {
+		if l, ok := Supported(w.tag); ok {
+			return l
+		}
+
+		lang := strings.SplitN(w.tag, "-", 2)[0]
+		for _, l := range Locales {
+			if strings.EqualFold(strings.SplitN(l, "-", 2)[0], lang) {
+				return l
+			}
+		}
+	}
+
+	return Default
+}
@@ -0,0 +1,37 @@
+package i18n_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cristiano-pacheco/go-api/core/i18n"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatch(t *testing.T) {
+	assert.Equal(t, i18n.PtBR, i18n.Match("pt-BR,pt;q=0.9,en;q=0.8"))
+	assert.Equal(t, i18n.PtBR, i18n.Match("pt-br"))
+	assert.Equal(t, i18n.PtBR, i18n.Match("pt-PT"))
+	assert.Equal(t, i18n.En, i18n.Match("en-US,pt;q=0.5"))
+	assert.Equal(t, i18n.PtBR, i18n.Match("fr;q=0.9, pt;q=0.8, en;q=0.1"))
+	assert.Equal(t, i18n.En, i18n.Match("pt;q=0, fr"))
+	assert.Equal(t, i18n.En, i18n.Match(""))
+}
+
+func TestMessage(t *testing.T) {
+	msg, ok := i18n.Message(i18n.PtBR, "max_length", "name", 255)
+	assert.True(t, ok)
+	assert.Equal(t, "name é muito longo (máximo de 255 caracteres)", msg)
+
+	msg, ok = i18n.Message("fr", "required", "name")
+	assert.True(t, ok)
+	assert.Equal(t, "name cannot be empty", msg)
+
+	_, ok = i18n.Message(i18n.PtBR, "unknown")
+	assert.False(t, ok)
+
+	ctx := i18n.WithLocale(context.Background(), i18n.PtBR)
+	assert.Equal(t, "Credenciais inválidas", i18n.T(ctx, "invalid_credentials"))
+	assert.Equal(t, "Invalid Credentials", i18n.T(context.Background(), "invalid_credentials"))
+	assert.Equal(t, "unknown", i18n.T(ctx, "unknown"))
+}
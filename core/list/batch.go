@@ -0,0 +1,205 @@
+package list
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/cristiano-pacheco/go-api/core/apperror"
+	"github.com/cristiano-pacheco/go-api/core/event"
+)
+
+// ErrInvalidBatch is returned when a batch fails validation, the results tell
+// which operations are invalid and nothing is applied
+var ErrInvalidBatch = errors.New("the batch has invalid operations")
+
+// BatchOperation is one create, update or delete of a batch. Items created in
+// the batch can be referenced as parent of the following ones through Ref and ParentRef.
+type BatchOperation struct {
+	Op        string    `json:"op"`
+	ID        int64     `json:"id"`
+	Ref       string    `json:"ref"`
+	ParentRef string    `json:"parent_ref"`
+	Item      *ListItem `json:"item"`
+}
+
+// BatchResult is the outcome of one operation of a batch
+type BatchResult struct {
+	Index   int    `json:"index"`
+	Op      string `json:"op"`
+	Ref     string `json:"ref,omitempty"`
+	ID      int64  `json:"id,omitempty"`
+	Version int64  `json:"version,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// BatchError tells which operation made a batch fail while it was applied
+type BatchError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("operation %d: %s", e.Index, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// ExecBatch validates every operation and then applies all of them in a single
+// transaction, either every operation is applied or none is
+func (s *Service) ExecBatch(listID int64, ops []*BatchOperation) ([]*BatchResult, error) {
+	results := make([]*BatchResult, len(ops))
+	invalid := false
+	refs := make(map[string]bool)
+
+	for i, op := range ops {
+		results[i] = &BatchResult{Index: i, Op: op.Op, Ref: op.Ref, ID: op.ID}
+
+		err := s.validateBatchOperation(listID, op, refs)
+		if err != nil {
+			results[i].Error = err.Error()
+			invalid = true
+		}
+	}
+
+	if invalid {
+		return results, ErrInvalidBatch
+	}
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	created := make(map[string]int64)
+	for i, op := range ops {
+		err = s.execBatchOperation(tx, listID, op, created, results[i])
+		if err != nil {
+			tx.Rollback()
+			results[i].Error = err.Error()
+			return results, &BatchError{Index: i, Err: err}
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, op := range ops {
+		switch op.Op {
+		case RevisionCreate:
+			s.publish(event.ItemCreated, listID, op.Item)
+		case RevisionUpdate:
+			s.publish(event.ItemUpdated, listID, op.Item)
+		case RevisionDelete:
+			s.publish(event.ItemRemoved, listID, map[string]int64{"id": results[i].ID})
+		}
+	}
+
+	return results, nil
+}
+
+func (s *Service) validateBatchOperation(listID int64, op *BatchOperation, refs map[string]bool) error {
+	if op.ParentRef != "" && !refs[op.ParentRef] {
+		return apperror.Validation("parent_ref %q is not created before this operation", op.ParentRef)
+	}
+
+	switch op.Op {
+	case RevisionCreate:
+		if op.Item == nil {
+			return apperror.Validation("item is required")
+		}
+		if op.Ref != "" {
+			if refs[op.Ref] {
+				return apperror.Validation("ref %q is used twice", op.Ref)
+			}
+			refs[op.Ref] = true
+		}
+		op.Item.ID = 0
+		op.Item.ListID = listID
+		setParentPlaceholder(op)
+		return s.validator.validateListItemCreationData(op.Item)
+	case RevisionUpdate:
+		if op.Item == nil {
+			return apperror.Validation("item is required")
+		}
+		op.Item.ID = op.ID
+		op.Item.ListID = listID
+		setParentPlaceholder(op)
+		return s.validator.validateListItemUpdateData(op.Item)
+	case RevisionDelete:
+		if op.ID == 0 {
+			return apperror.Validation("invalid ID")
+		}
+		if op.ParentRef != "" {
+			return apperror.Validation("parent_ref cannot be used on delete")
+		}
+		return nil
+	}
+
+	return apperror.Validation("unknown operation %q", op.Op)
+}
+
+// setParentPlaceholder fills the parent of an item referenced by parent_ref,
+// it is replaced by the ID of the parent once the parent is created
+func setParentPlaceholder(op *BatchOperation) {
+	if op.ParentRef != "" {
+		placeholder := int64(-1)
+		op.Item.ParentID = &placeholder
+	}
+}
+
+func (s *Service) execBatchOperation(tx *sql.Tx, listID int64, op *BatchOperation, created map[string]int64, r *BatchResult) error {
+	if op.ParentRef != "" {
+		parentID := created[op.ParentRef]
+		op.Item.ParentID = &parentID
+	}
+
+	if op.Op == RevisionCreate {
+		err := s.storeItem(tx, op.Item)
+		if err != nil {
+			return err
+		}
+
+		if op.Ref != "" {
+			created[op.Ref] = op.Item.ID
+		}
+		r.ID, r.Version = op.Item.ID, op.Item.Version
+
+		return s.recordItemRevision(tx, RevisionCreate, op.Item.ID, listID, nil)
+	}
+
+	var current *ListItem
+	var err error
+	if op.Op == RevisionDelete {
+		current, err = getItemSnapshot(tx, op.ID)
+	} else {
+		current, err = getItem(tx, op.ID)
+	}
+	if err != nil {
+		return err
+	}
+	if current.ListID != listID {
+		return apperror.Validation("item %d belongs to another list", op.ID)
+	}
+
+	if op.Op == RevisionDelete {
+		_, err = s.removeItem(tx, op.ID)
+		if err != nil {
+			return err
+		}
+
+		return s.recordItemRevision(tx, RevisionDelete, op.ID, listID, current)
+	}
+
+	err = s.updateItem(tx, op.Item)
+	if err != nil {
+		return err
+	}
+	r.Version = op.Item.Version
+
+	return s.recordItemRevision(tx, RevisionUpdate, op.ID, listID, current)
+}
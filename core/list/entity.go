@@ -1,14 +1,22 @@
 package list
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // List struct
 type List struct {
-	ID        int64     `json:"id"`
-	Name      string    `json:"name"`
-	IsActive  bool      `json:"is_active"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        int64      `json:"id"`
+	Name      string     `json:"name" validate:"required,max=255"`
+	Notes     string     `json:"notes" validate:"max=2000"`
+	IsActive  bool       `json:"is_active"`
+	Version   int64      `json:"version"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// Items is only filled when the items are expanded
+	Items []*ListItem `json:"items,omitempty"`
 }
 
 type Category struct {
@@ -20,11 +28,66 @@ type Category struct {
 }
 
 type ListItem struct {
-	ID           int64     `json:"id"`
-	ListID       int64     `json:"list_id"`
-	CategoryID   int64     `json:"category_id"`
-	CategoryName string    `json:"category_name"`
-	Name         string    `json:"name"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID           int64       `json:"id"`
+	ListID       int64       `json:"list_id" validate_create:"required"`
+	ParentID     *int64      `json:"parent_id" validate:"parent"`
+	CategoryID   int64       `json:"category_id" validate:"required"`
+	CategoryName string      `json:"category_name"`
+	Category     *Category   `json:"category,omitempty"`
+	Name         string      `json:"name" validate:"required,max=255"`
+	Notes        string      `json:"notes" validate:"max=2000"`
+	IsChecked    bool        `json:"is_checked"`
+	Price        *float64    `json:"price" validate:"min=0"`
+	DueAt        *time.Time  `json:"due_at"`
+	RemindAt     *time.Time  `json:"remind_at" validate:"ltefield=DueAt"`
+	Tags         []string    `json:"tags"`
+	Children     []*ListItem `json:"children,omitempty"`
+	Version      int64       `json:"version"`
+	CreatedAt    time.Time   `json:"created_at"`
+	UpdatedAt    time.Time   `json:"updated_at"`
+	DeletedAt    *time.Time  `json:"deleted_at,omitempty"`
+}
+
+// Tag is a free-form label a user puts on list items
+type Tag struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"user_id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Stats summarizes the items of a list, sub-items are counted instead of their parents
+type Stats struct {
+	ListID         int64            `json:"list_id"`
+	Name           string           `json:"name"`
+	TotalItems     int              `json:"total_items"`
+	CheckedItems   int              `json:"checked_items"`
+	UncheckedItems int              `json:"unchecked_items"`
+	Progress       float64          `json:"progress"`
+	EstimatedCost  *float64         `json:"estimated_cost"`
+	Categories     []*CategoryStats `json:"categories"`
+	LastActivityAt time.Time        `json:"last_activity_at"`
+}
+
+// CategoryStats counts the items of a list in one category
+type CategoryStats struct {
+	CategoryID     int64  `json:"category_id"`
+	CategoryName   string `json:"category_name"`
+	TotalItems     int    `json:"total_items"`
+	CheckedItems   int    `json:"checked_items"`
+	UncheckedItems int    `json:"unchecked_items"`
+}
+
+// Revision is one recorded change of a list or of one of its items, Before and
+// After hold the JSON of the entity and are null on creates and deletes respectively
+type Revision struct {
+	ID         int64           `json:"id"`
+	EntityType string          `json:"entity_type"`
+	EntityID   int64           `json:"entity_id"`
+	ListID     int64           `json:"list_id"`
+	Action     string          `json:"action"`
+	ActorID    *int64          `json:"actor_id"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	CreatedAt  time.Time       `json:"created_at"`
 }
@@ -0,0 +1,123 @@
+package list
+
+import (
+	"strings"
+
+	"github.com/cristiano-pacheco/go-api/core/apperror"
+)
+
+// Expand tells which related resources are embedded in the lists
+type Expand struct {
+	Items         bool
+	ItemsCategory bool
+}
+
+// ParseExpand reads a comma separated expand parameter, e.g. items,items.category.
+// Expanding the category of the items expands the items as well.
+func ParseExpand(v string) (*Expand, error) {
+	e := &Expand{}
+
+	for _, name := range strings.Split(v, ",") {
+		switch strings.TrimSpace(name) {
+		case "":
+		case "items":
+			e.Items = true
+		case "items.category":
+			e.Items = true
+			e.ItemsCategory = true
+		case "members":
+			return nil, apperror.Validation("members cannot be expanded, lists are not shared with other users")
+		default:
+			return nil, apperror.Validation("cannot expand %q", name)
+		}
+	}
+
+	return e, nil
+}
+
+// Embed fills the expanded resources of the lists. The items of every list are
+// read with a single query, and so are their tags and categories, whatever the
+// number of lists.
+func (s *Service) Embed(lists []*List, e *Expand) error {
+	if e == nil || !e.Items || len(lists) == 0 {
+		return nil
+	}
+
+	byID := make(map[int64]*List, len(lists))
+	ids := make([]interface{}, len(lists))
+	for i, l := range lists {
+		l.Items = []*ListItem{}
+		byID[l.ID] = l
+		ids[i] = l.ID
+	}
+
+	items, err := queryItems(
+		s.DB,
+		"select "+itemColumns+" from list_item as li left join category c on li.category_id = c.id where li.list_id in ("+placeholders(len(ids))+") and li.deleted_at is null order by li.id",
+		ids...,
+	)
+	if err != nil {
+		return err
+	}
+
+	err = loadTags(s.DB, items)
+	if err != nil {
+		return err
+	}
+
+	if e.ItemsCategory {
+		err = s.loadCategories(items)
+		if err != nil {
+			return err
+		}
+	}
+
+	grouped := make(map[int64][]*ListItem, len(lists))
+	for _, li := range items {
+		grouped[li.ListID] = append(grouped[li.ListID], li)
+	}
+	for listID, listItems := range grouped {
+		byID[listID].Items = buildTree(listItems)
+	}
+
+	return nil
+}
+
+// loadCategories fills the category of the items with one query
+func (s *Service) loadCategories(items []*ListItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	seen := make(map[int64]bool)
+	var ids []interface{}
+	for _, li := range items {
+		if !seen[li.CategoryID] {
+			seen[li.CategoryID] = true
+			ids = append(ids, li.CategoryID)
+		}
+	}
+
+	rows, err := s.DB.Query("select id, name, type, created_at, updated_at from category where id in ("+placeholders(len(ids))+")", ids...)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	byID := make(map[int64]*Category, len(ids))
+	for rows.Next() {
+		var c Category
+		err := rows.Scan(&c.ID, &c.Name, &c.Type, &c.CreatedAt, &c.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		byID[c.ID] = &c
+	}
+
+	for _, li := range items {
+		li.Category = byID[li.CategoryID]
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,415 @@
+package list
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/cristiano-pacheco/go-api/core/apperror"
+	"github.com/cristiano-pacheco/go-api/core/auth"
+	"github.com/cristiano-pacheco/go-api/core/event"
+)
+
+// revision actions
+const (
+	RevisionCreate  = "create"
+	RevisionUpdate  = "update"
+	RevisionDelete  = "delete"
+	RevisionRestore = "restore"
+)
+
+// revision entity types
+const (
+	EntityList     = "list"
+	EntityListItem = "list_item"
+)
+
+// GetHistory return the revisions of a list and its items, newest first
+func (s *Service) GetHistory(listID int64) ([]*Revision, error) {
+	rows, err := s.DB.Query(
+		"select id, entity_type, entity_id, list_id, action, actor_id, before_data, after_data, created_at from revision where list_id = ? order by id desc",
+		listID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	result := []*Revision{}
+	for rows.Next() {
+		r, err := scanRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, r)
+	}
+
+	return result, rows.Err()
+}
+
+// Restore brings a list or an item back to the state recorded by a revision.
+// Deletes are undone by restoring the state before them, any other revision
+// by restoring the state after it. The restore is recorded as a new revision.
+func (s *Service) Restore(listID int64, revisionID int64) (*Revision, error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := scanRevision(tx.QueryRow(
+		"select id, entity_type, entity_id, list_id, action, actor_id, before_data, after_data, created_at from revision where id = ? and list_id = ?",
+		revisionID, listID,
+	))
+	if err != nil {
+		tx.Rollback()
+		return nil, apperror.FromDB(err, "revision")
+	}
+
+	state := target.After
+	if target.Action == RevisionDelete {
+		state = target.Before
+	}
+
+	var r *Revision
+	if target.EntityType == EntityList {
+		r, err = s.restoreList(tx, state)
+	} else {
+		r, err = s.restoreItem(tx, state)
+	}
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	if r.EntityType == EntityList {
+		s.publish(event.ListUpdated, r.ListID, r.After)
+	} else {
+		s.publish(event.ItemUpdated, r.ListID, r.After)
+	}
+
+	return r, nil
+}
+
+func (s *Service) restoreList(tx *sql.Tx, state json.RawMessage) (*Revision, error) {
+	// a deleted list carries its items so restoring it brings them back as well
+	var snapshot List
+	err := json.Unmarshal(state, &snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	before, err := getList(tx, snapshot.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		before = nil
+		err = s.bringBackList(tx, &snapshot)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot.Version = 0
+	err = s.updateList(tx, &snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.recordRevision(tx, EntityList, RevisionRestore, snapshot.ID, snapshot.ID, before, func() (interface{}, error) {
+		return getList(tx, snapshot.ID)
+	})
+}
+
+// bringBackList takes a removed list out of the trash, or recreates it when it was purged
+func (s *Service) bringBackList(tx *sql.Tx, snapshot *List) error {
+	found, err := untrashList(tx, snapshot.ID)
+	if err != nil || found {
+		return err
+	}
+
+	return s.insertList(tx, snapshot)
+}
+
+func (s *Service) restoreItem(tx *sql.Tx, state json.RawMessage) (*Revision, error) {
+	var li ListItem
+	err := json.Unmarshal(state, &li)
+	if err != nil {
+		return nil, err
+	}
+
+	var exists bool
+	err = tx.QueryRow("select exists(select 1 from list where id = ? and deleted_at is null)", li.ListID).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, apperror.Conflict("the list %d was deleted, restore the list first", li.ListID)
+	}
+
+	before, err := getItem(tx, li.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		before = nil
+		var found bool
+		_, found, err = untrashItem(tx, li.ID)
+		if err == nil && !found {
+			err = s.insertSubtree(tx, &li)
+			if err == nil {
+				err = rollUp(tx, li.ParentID)
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// the item stays where it is now, its old parent may be gone
+	var current ListItem
+	err = tx.QueryRow("select parent_id from list_item where id = ?", li.ID).Scan(&current.ParentID)
+	if err != nil {
+		return nil, err
+	}
+
+	li.ParentID = current.ParentID
+	li.Version = 0
+	err = s.updateItem(tx, &li)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.restoreTags(tx, &li)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.recordRevision(tx, EntityListItem, RevisionRestore, li.ID, li.ListID, before, func() (interface{}, error) {
+		return getItem(tx, li.ID)
+	})
+}
+
+// insertList recreates a deleted list and its items keeping their IDs
+func (s *Service) insertList(tx *sql.Tx, snapshot *List) error {
+	_, err := tx.Exec(
+		"insert into list (id, name, notes, is_active, created_at) values (?, ?, ?, ?, ?)",
+		snapshot.ID, snapshot.Name, snapshot.Notes, snapshot.IsActive, snapshot.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, li := range snapshot.Items {
+		err = s.insertSubtree(tx, li)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// insertSubtree recreates a deleted item and its children keeping their IDs,
+// an item whose parent is gone is restored at the top of the list
+func (s *Service) insertSubtree(tx *sql.Tx, li *ListItem) error {
+	if li.ParentID != nil {
+		var exists bool
+		err := tx.QueryRow("select exists(select 1 from list_item where id = ? and deleted_at is null)", *li.ParentID).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			li.ParentID = nil
+		}
+	}
+
+	_, err := tx.Exec(
+		"insert into list_item (id, list_id, parent_id, category_id, name, notes, is_checked, price, due_at, remind_at, created_at) values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		li.ID, li.ListID, li.ParentID, li.CategoryID, li.Name, li.Notes, li.IsChecked, li.Price, li.DueAt, li.RemindAt, li.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	err = s.restoreTags(tx, li)
+	if err != nil {
+		return err
+	}
+
+	for _, child := range li.Children {
+		err = s.insertSubtree(tx, child)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// restoreTags puts the tags back on an item, tags are owned by users so they
+// are recreated for the user doing the restore
+func (s *Service) restoreTags(tx *sql.Tx, li *ListItem) error {
+	actorID, ok := auth.UserIDFromContext(s.ctx)
+	if !ok {
+		return nil
+	}
+
+	return setItemTags(tx, li.ID, actorID, li.Tags)
+}
+
+func (s *Service) recordListRevision(tx *sql.Tx, action string, listID int64, before interface{}) error {
+	_, err := s.recordRevision(tx, EntityList, action, listID, listID, before, func() (interface{}, error) {
+		return getList(tx, listID)
+	})
+	return err
+}
+
+func (s *Service) recordItemRevision(tx *sql.Tx, action string, itemID int64, listID int64, before interface{}) error {
+	_, err := s.recordRevision(tx, EntityListItem, action, itemID, listID, before, func() (interface{}, error) {
+		return getItem(tx, itemID)
+	})
+	return err
+}
+
+// recordRevision stores a revision inside the transaction of the change, after
+// loads the new state and is not called for deletes
+func (s *Service) recordRevision(tx *sql.Tx, entityType string, action string, entityID int64, listID int64, before interface{}, after func() (interface{}, error)) (*Revision, error) {
+	r := &Revision{
+		EntityType: entityType,
+		EntityID:   entityID,
+		ListID:     listID,
+		Action:     action,
+	}
+
+	actorID, ok := auth.UserIDFromContext(s.ctx)
+	if ok {
+		r.ActorID = &actorID
+	}
+
+	var err error
+	r.Before, err = marshalState(before)
+	if err != nil {
+		return nil, err
+	}
+
+	if action != RevisionDelete {
+		state, err := after()
+		if err != nil {
+			return nil, err
+		}
+
+		r.After, err = marshalState(state)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	res, err := tx.Exec(
+		"insert into revision (entity_type, entity_id, list_id, action, actor_id, before_data, after_data) values (?, ?, ?, ?, ?, ?, ?)",
+		r.EntityType, r.EntityID, r.ListID, r.Action, r.ActorID, nullableJSON(r.Before), nullableJSON(r.After),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	r.ID, err = res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	return r, tx.QueryRow("select created_at from revision where id = ?", r.ID).Scan(&r.CreatedAt)
+}
+
+// getListSnapshot loads a list with its items, as kept when the list is deleted
+func getListSnapshot(q querier, ID int64) (*List, error) {
+	l, err := getList(q, ID)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := getItemTree(q, ID)
+	if err != nil {
+		return nil, err
+	}
+
+	l.Items = items
+	return l, nil
+}
+
+// getItemSnapshot loads an item with its sub-items, as kept when the item is deleted
+func getItemSnapshot(q querier, ID int64) (*ListItem, error) {
+	li, err := getItem(q, ID)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := getItemTree(q, li.ListID)
+	if err != nil {
+		return nil, err
+	}
+
+	if found := findItem(items, ID); found != nil {
+		li.Children = found.Children
+	}
+
+	return li, nil
+}
+
+func findItem(items []*ListItem, ID int64) *ListItem {
+	for _, li := range items {
+		if li.ID == ID {
+			return li
+		}
+		if found := findItem(li.Children, ID); found != nil {
+			return found
+		}
+	}
+
+	return nil
+}
+
+// marshalState turns a state into JSON, nil states stay nil
+func marshalState(state interface{}) (json.RawMessage, error) {
+	switch v := state.(type) {
+	case nil:
+		return nil, nil
+	case *List:
+		if v == nil {
+			return nil, nil
+		}
+	case *ListItem:
+		if v == nil {
+			return nil, nil
+		}
+	}
+
+	return json.Marshal(state)
+}
+
+func nullableJSON(data json.RawMessage) interface{} {
+	if data == nil {
+		return nil
+	}
+
+	return string(data)
+}
+
+func scanRevision(row scanner) (*Revision, error) {
+	var r Revision
+	var before, after []byte
+
+	err := row.Scan(&r.ID, &r.EntityType, &r.EntityID, &r.ListID, &r.Action, &r.ActorID, &before, &after, &r.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if before != nil {
+		r.Before = json.RawMessage(before)
+	}
+	if after != nil {
+		r.After = json.RawMessage(after)
+	}
+
+	return &r, nil
+}
@@ -0,0 +1,62 @@
+package list
+
+import "github.com/cristiano-pacheco/go-api/core/query"
+
+// ListQuerySpec lists the fields lists can be sorted and filtered on
+var ListQuerySpec = &query.Spec{
+	Fields: map[string]*query.Field{
+		"id": {
+			Column: "id", Kind: query.Int, Sortable: true,
+			Value: func(row interface{}) interface{} { return row.(*List).ID },
+		},
+		"name": {
+			Column: "name", Kind: query.String, Sortable: true,
+			Value: func(row interface{}) interface{} { return row.(*List).Name },
+		},
+		"created_at": {
+			Column: "created_at", Kind: query.Time, Sortable: true,
+			Value: func(row interface{}) interface{} { return row.(*List).CreatedAt },
+		},
+		"updated_at": {
+			Column: "updated_at", Kind: query.Time, Sortable: true,
+			Value: func(row interface{}) interface{} { return row.(*List).UpdatedAt },
+		},
+		"is_active": {Column: "is_active", Kind: query.Bool, Filterable: true},
+	},
+	DefaultSort:  "id",
+	IDColumn:     "id",
+	ID:           func(row interface{}) int64 { return row.(*List).ID },
+	SearchColumn: "name",
+}
+
+// ItemQuerySpec lists the fields the items of a list can be sorted and filtered
+// on, they apply to the root items and sub-items always follow their parent
+var ItemQuerySpec = &query.Spec{
+	Fields: map[string]*query.Field{
+		"id": {
+			Column: "li.id", Kind: query.Int, Sortable: true,
+			Value: func(row interface{}) interface{} { return row.(*ListItem).ID },
+		},
+		"name": {
+			Column: "li.name", Kind: query.String, Sortable: true,
+			Value: func(row interface{}) interface{} { return row.(*ListItem).Name },
+		},
+		"created_at": {
+			Column: "li.created_at", Kind: query.Time, Sortable: true,
+			Value: func(row interface{}) interface{} { return row.(*ListItem).CreatedAt },
+		},
+		"updated_at": {
+			Column: "li.updated_at", Kind: query.Time, Sortable: true,
+			Value: func(row interface{}) interface{} { return row.(*ListItem).UpdatedAt },
+		},
+		"checked": {
+			Column: "li.is_checked", Kind: query.Bool, Sortable: true, Filterable: true,
+			Value: func(row interface{}) interface{} { return row.(*ListItem).IsChecked },
+		},
+		"category_id": {Column: "li.category_id", Kind: query.Int, Filterable: true},
+	},
+	DefaultSort:  "id",
+	IDColumn:     "li.id",
+	ID:           func(row interface{}) int64 { return row.(*ListItem).ID },
+	SearchColumn: "li.name",
+}
@@ -1,30 +1,76 @@
 package list
 
 import (
+	"context"
 	"database/sql"
-	"fmt"
+	"strings"
+	"time"
 
+	"github.com/cristiano-pacheco/go-api/core/apperror"
+	"github.com/cristiano-pacheco/go-api/core/event"
+	"github.com/cristiano-pacheco/go-api/core/logger"
+	"github.com/cristiano-pacheco/go-api/core/query"
 	_ "github.com/go-sql-driver/mysql" // OK
 )
 
 // UseCase Define the interface with functions that will be used
 type UseCase interface {
-	GetAll() ([]*List, error)
+	WithContext(ctx context.Context) UseCase
+	GetAll(p *query.Params) ([]*List, *query.Result, error)
 	Get(ID int64) (*List, error)
 	Store(l *List) error
 	Update(l *List) error
 	Remove(ID int64) error
-	GetAllItems(listID int64) ([]*ListItem, error)
+	GetAllItems(listID int64, p *query.Params) ([]*ListItem, *query.Result, error)
 	GetItem(ID int64) (*ListItem, error)
 	StoreItem(li *ListItem) error
 	UpdateItem(li *ListItem) error
 	RemoveItem(ID int64) error
+	GetTags(userID int64, prefix string) ([]*Tag, error)
+	SetItemTags(itemID int64, userID int64, tags []string) error
+	GetItemsByTags(userID int64, tags []string, matchAll bool) ([]*ListItem, error)
+	GetStats(ID int64) (*Stats, error)
+	GetAllStats() ([]*Stats, error)
+	GetHistory(listID int64) ([]*Revision, error)
+	GetTrash() ([]*List, error)
+	RestoreFromTrash(ID int64) error
+	GetItemTrash(listID int64) ([]*ListItem, error)
+	RestoreItemFromTrash(ID int64) error
+	Purge(before time.Time) (int64, error)
+	GetChanges(cursor string) (*Changes, error)
+	ApplyMutations(mutations []*Mutation) []*MutationResult
+	ExecBatch(listID int64, ops []*BatchOperation) ([]*BatchResult, error)
+	Embed(lists []*List, e *Expand) error
+	Restore(listID int64, revisionID int64) (*Revision, error)
+}
+
+// listColumns is the column list shared by the list queries
+const listColumns = "id, name, notes, is_active, version, created_at, updated_at, deleted_at"
+
+// itemColumns is the column list shared by the list item queries, read it with scanItem
+const itemColumns = "li.id, li.list_id, li.parent_id, li.category_id, c.name as category_name, li.name, li.notes, li.is_checked, li.price, li.due_at, li.remind_at, li.version, li.created_at, li.updated_at, li.deleted_at"
+
+// tagSuggestionsLimit is the maximum number of tags returned by GetTags
+const tagSuggestionsLimit = 20
+
+// scanner is implemented by *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// querier is implemented by *sql.DB and *sql.Tx
+type querier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
 }
 
 // Service define the struct for service
 type Service struct {
-	DB        *sql.DB
-	validator *Validator
+	DB             *sql.DB
+	Events         event.Publisher
+	TrashRetention time.Duration // sync cursors older than this are rejected, their tombstones may be purged
+	validator      *Validator
+	ctx            context.Context
 }
 
 // NewService constructor
@@ -32,54 +78,66 @@ func NewService(db *sql.DB, v *Validator) *Service {
 	return &Service{
 		DB:        db,
 		validator: v,
+		ctx:       context.Background(),
 	}
 }
 
-// GetAll return all records from the database
-func (s *Service) GetAll() ([]*List, error) {
-	var result []*List
+// WithContext returns a copy of the service bound to a request, the user
+// authenticated in ctx is recorded as the author of the changes
+func (s *Service) WithContext(ctx context.Context) UseCase {
+	c := *s
+	c.ctx = ctx
+	return &c
+}
+
+// GetAll return a page of records from the database, a nil p returns the first page
+func (s *Service) GetAll(p *query.Params) ([]*List, *query.Result, error) {
+	if p == nil {
+		p = query.New(ListQuerySpec)
+	}
+
+	result := []*List{}
 
-	rows, err := s.DB.Query("select id, name, is_active, created_at, updated_at from list")
+	conds, args := p.PageConditions()
+	conds = append([]string{"deleted_at is null"}, conds...)
+	rows, err := s.DB.Query("select "+listColumns+" from list"+query.Where(conds)+p.OrderBy()+p.LimitClause(), args...)
 
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	defer rows.Close()
 
 	for rows.Next() {
 		var u List
-		err := rows.Scan(&u.ID, &u.Name, &u.IsActive, &u.CreatedAt, &u.UpdatedAt)
+		err := rows.Scan(&u.ID, &u.Name, &u.Notes, &u.IsActive, &u.Version, &u.CreatedAt, &u.UpdatedAt, &u.DeletedAt)
 
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
 		result = append(result, &u)
 	}
 
-	return result, nil
-}
-
-// Get the records from the database
-func (s *Service) Get(ID int64) (*List, error) {
-	var l List
-
-	stmt, err := s.DB.Prepare("select id, name, is_active, created_at, updated_at from list where id = ?")
-
-	if err != nil {
-		return nil, err
+	res := &query.Result{}
+	if p.HasMore(len(result)) {
+		result = result[:p.Limit]
+		res.NextCursor = p.NextCursor(result[len(result)-1])
 	}
 
-	defer stmt.Close()
-
-	err = stmt.QueryRow(ID).Scan(&l.ID, &l.Name, &l.IsActive, &l.CreatedAt, &l.UpdatedAt)
-
+	conds, args = p.Conditions()
+	conds = append([]string{"deleted_at is null"}, conds...)
+	err = s.DB.QueryRow("select count(1) from list"+query.Where(conds), args...).Scan(&res.Total)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return &l, nil
+	return result, res, nil
+}
+
+// Get the records from the database
+func (s *Service) Get(ID int64) (*List, error) {
+	return getList(s.DB, ID)
 }
 
 // Store a record in the database
@@ -94,25 +152,31 @@ func (s *Service) Store(l *List) error {
 		return err
 	}
 
-	stmt, err := tx.Prepare("insert into list(id, name, is_active) values (?, ?, ?)")
+	err = s.storeList(tx, l)
 	if err != nil {
+		tx.Rollback()
 		return err
 	}
 
-	defer stmt.Close()
-
-	_, err = stmt.Exec(l.ID, l.Name, l.IsActive)
+	err = s.recordListRevision(tx, RevisionCreate, l.ID, nil)
 	if err != nil {
 		tx.Rollback()
 		return err
 	}
 
-	tx.Commit()
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	s.publish(event.ListCreated, l.ID, l)
 
 	return nil
 }
 
-// Update an record in the database
+// Update an record in the database. The update only happens if l.Version is
+// the current version of the list, otherwise an *apperror.VersionConflictError
+// is returned. A zero version skips the check. On success l.Version is the new version.
 func (s *Service) Update(l *List) error {
 	err := s.validator.validateUpdateData(l)
 	if err != nil {
@@ -124,27 +188,44 @@ func (s *Service) Update(l *List) error {
 		return err
 	}
 
-	stmt, err := tx.Prepare("update list set name =?, is_active = ? where id = ?")
+	before, err := getList(tx, l.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = s.updateList(tx, l)
 	if err != nil {
 		tx.Rollback()
 		return err
 	}
 
-	_, err = stmt.Exec(l.Name, l.IsActive, l.ID)
+	err = s.recordListRevision(tx, RevisionUpdate, l.ID, before)
 	if err != nil {
 		tx.Rollback()
 		return err
 	}
 
-	tx.Commit()
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	s.publish(event.ListUpdated, l.ID, l)
 
 	return nil
 }
 
-// Remove an record from the database
+// Remove moves a list and its items to the trash, they are deleted for good by Purge
 func (s *Service) Remove(ID int64) error {
+	return s.remove(ID, 0)
+}
+
+// remove moves a list to the trash, when version is not zero the list must
+// still have it
+func (s *Service) remove(ID int64, version int64) error {
 	if ID == 0 {
-		return fmt.Errorf("invalid ID")
+		return apperror.Validation("invalid ID")
 	}
 
 	tx, err := s.DB.Begin()
@@ -152,81 +233,126 @@ func (s *Service) Remove(ID int64) error {
 		return err
 	}
 
-	_, err = tx.Exec("delete from list where id = ?", ID)
+	err = lockVersion(tx, "list", ID, version)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	before, err := getListSnapshot(tx, ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = trashList(tx, ID)
 	if err != nil {
 		tx.Rollback()
 		return err
 	}
 
-	tx.Commit()
+	err = s.recordListRevision(tx, RevisionDelete, ID, before)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	s.publish(event.ListRemoved, ID, map[string]int64{"id": ID})
 
 	return nil
 }
 
-// GetAllItems return all records from the database
-func (s *Service) GetAllItems(listID int64) ([]*ListItem, error) {
-	var result []*ListItem
+// GetAllItems return a page of the root items of the list, sub-items are nested
+// in the children of their parent. A nil p returns the first page.
+func (s *Service) GetAllItems(listID int64, p *query.Params) ([]*ListItem, *query.Result, error) {
+	if p == nil {
+		p = query.New(ItemQuerySpec)
+	}
 
-	sql := `
-		select li.id, li.list_id, li.category_id, c.name as category_name, li.name, li.created_at, li.updated_at 
-		from list_item as li
-		left join category c on li.category_id = c.id
-		where li.list_id = ?
-	`
+	from := " from list_item as li left join category c on li.category_id = c.id"
 
-	stmt, err := s.DB.Prepare(sql)
+	conds, args := p.PageConditions()
+	conds = append([]string{"li.list_id = ?", "li.parent_id is null", "li.deleted_at is null"}, conds...)
+	args = append([]interface{}{listID}, args...)
 
+	result, err := queryItems(s.DB, "select "+itemColumns+from+query.Where(conds)+p.OrderBy()+p.LimitClause(), args...)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	defer stmt.Close()
+	res := &query.Result{}
+	if p.HasMore(len(result)) {
+		result = result[:p.Limit]
+		res.NextCursor = p.NextCursor(result[len(result)-1])
+	}
 
-	rows, err := stmt.Query(listID)
+	conds, args = p.Conditions()
+	conds = append([]string{"li.list_id = ?", "li.parent_id is null", "li.deleted_at is null"}, conds...)
+	args = append([]interface{}{listID}, args...)
+	err = s.DB.QueryRow("select count(1) from list_item as li"+query.Where(conds), args...).Scan(&res.Total)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	for rows.Next() {
-		var li ListItem
-		err := rows.Scan(&li.ID, &li.ListID, &li.CategoryID, &li.CategoryName, &li.Name, &li.CreatedAt, &li.UpdatedAt)
+	// the sub-items of the page are loaded one level at a time
+	all := result
+	parents := result
+	for depth := 1; depth < MaxItemDepth && len(parents) > 0; depth++ {
+		ids := make([]interface{}, len(parents))
+		for i, li := range parents {
+			ids[i] = li.ID
+		}
 
+		parents, err = queryItems(
+			s.DB,
+			"select "+itemColumns+from+" where li.parent_id in ("+placeholders(len(ids))+") and li.deleted_at is null order by li.id",
+			ids...,
+		)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
+		all = append(all, parents...)
+	}
 
-		result = append(result, &li)
+	err = loadTags(s.DB, all)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return result, nil
+	return buildTree(all), res, nil
 }
 
-// GetItem the record from the database
-func (s *Service) GetItem(ID int64) (*ListItem, error) {
-	var li ListItem
-
-	sql := `
-		select li.id, li.list_id, li.category_id, c.name as category_name, li.name, li.created_at, li.updated_at 
-		from list_item as li
-		left join category c on li.category_id = c.id
-		where li.id = ?
-	`
-
-	stmt, err := s.DB.Prepare(sql)
-
+func queryItems(q querier, stmt string, args ...interface{}) ([]*ListItem, error) {
+	rows, err := q.Query(stmt, args...)
 	if err != nil {
 		return nil, err
 	}
 
-	defer stmt.Close()
-
-	err = stmt.QueryRow(ID).Scan(&li.ID, &li.ListID, &li.CategoryID, &li.CategoryName, &li.Name, &li.CreatedAt, &li.UpdatedAt)
+	defer rows.Close()
 
-	if err != nil {
-		return nil, err
+	result := []*ListItem{}
+	for rows.Next() {
+		li, err := scanItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, li)
 	}
 
-	return &li, nil
+	return result, rows.Err()
 }
 
-// Store a record in the database
+// GetItem the record from the database
+func (s *Service) GetItem(ID int64) (*ListItem, error) {
+	return getItem(s.DB, ID)
+}
+
+// StoreItem a record in the database
 func (s *Service) StoreItem(li *ListItem) error {
 	err := s.validator.validateListItemCreationData(li)
 	if err != nil {
@@ -238,25 +364,29 @@ func (s *Service) StoreItem(li *ListItem) error {
 		return err
 	}
 
-	stmt, err := tx.Prepare("insert into list_item (id, list_id, category_id, name) values (?, ?, ?, ?)")
+	err = s.storeItem(tx, li)
 	if err != nil {
+		tx.Rollback()
 		return err
 	}
 
-	defer stmt.Close()
-
-	_, err = stmt.Exec(li.ID, li.ListID, li.CategoryID, li.Name)
+	err = s.recordItemRevision(tx, RevisionCreate, li.ID, li.ListID, nil)
 	if err != nil {
 		tx.Rollback()
 		return err
 	}
 
-	tx.Commit()
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	s.publish(event.ItemCreated, li.ListID, li)
 
 	return nil
 }
 
-// Update an record in the database
+// UpdateItem an record in the database, li.Version is checked the same way as in Update
 func (s *Service) UpdateItem(li *ListItem) error {
 	err := s.validator.validateListItemUpdateData(li)
 	if err != nil {
@@ -268,27 +398,249 @@ func (s *Service) UpdateItem(li *ListItem) error {
 		return err
 	}
 
-	stmt, err := tx.Prepare("update list_item set category_id=?, name =? where id = ?")
+	before, err := getItem(tx, li.ID)
 	if err != nil {
 		tx.Rollback()
 		return err
 	}
 
-	_, err = stmt.Exec(li.CategoryID, li.Name, li.ID)
+	err = s.updateItem(tx, li)
 	if err != nil {
 		tx.Rollback()
 		return err
 	}
 
-	tx.Commit()
+	err = s.recordItemRevision(tx, RevisionUpdate, li.ID, li.ListID, before)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	s.publish(event.ItemUpdated, li.ListID, li)
 
 	return nil
 }
 
-// Remove an record from the database
+// RemoveItem moves an item and its sub-items to the trash
 func (s *Service) RemoveItem(ID int64) error {
+	return s.removeItemVersion(ID, 0)
+}
+
+// removeItemVersion moves an item to the trash, when version is not zero the
+// item must still have it
+func (s *Service) removeItemVersion(ID int64, version int64) error {
 	if ID == 0 {
-		return fmt.Errorf("invalid ID")
+		return apperror.Validation("invalid ID")
+	}
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	err = lockVersion(tx, "list_item", ID, version)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	before, err := getItemSnapshot(tx, ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	listID, err := s.removeItem(tx, ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = s.recordItemRevision(tx, RevisionDelete, ID, listID, before)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	s.publish(event.ItemRemoved, listID, map[string]int64{"id": ID})
+
+	return nil
+}
+
+func (s *Service) storeList(tx *sql.Tx, l *List) error {
+	stmt, err := tx.Prepare("insert into list(id, name, notes, is_active) values (?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+
+	defer stmt.Close()
+
+	res, err := stmt.Exec(l.ID, l.Name, l.Notes, l.IsActive)
+	if err != nil {
+		return apperror.FromDB(err, "list")
+	}
+
+	l.ID, err = res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	l.Version = 1
+
+	return nil
+}
+
+func (s *Service) updateList(tx *sql.Tx, l *List) error {
+	var err error
+	l.Version, err = versionedUpdate(tx, "list", l.ID, l.Version, "name = ?, notes = ?, is_active = ?", l.Name, l.Notes, l.IsActive)
+	return err
+}
+
+func (s *Service) storeItem(tx *sql.Tx, li *ListItem) error {
+	err := checkParent(tx, li)
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare("insert into list_item (id, list_id, parent_id, category_id, name, notes, is_checked, price, due_at, remind_at) values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+
+	defer stmt.Close()
+
+	res, err := stmt.Exec(li.ID, li.ListID, li.ParentID, li.CategoryID, li.Name, li.Notes, li.IsChecked, li.Price, li.DueAt, li.RemindAt)
+	if err != nil {
+		return apperror.FromDB(err, "list item")
+	}
+
+	li.ID, err = res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	li.Version = 1
+
+	return rollUp(tx, li.ParentID)
+}
+
+func (s *Service) updateItem(tx *sql.Tx, li *ListItem) error {
+	var current ListItem
+	err := tx.QueryRow(
+		"select list_id, parent_id, is_checked from list_item where id = ? and deleted_at is null for update", li.ID,
+	).Scan(&current.ListID, &current.ParentID, &current.IsChecked)
+	if err != nil {
+		return err
+	}
+
+	// an item is only updated through the list it belongs to, items without a
+	// list, e.g. from a sync mutation, keep their own
+	if li.ListID != 0 && li.ListID != current.ListID {
+		return apperror.NotFound("list item not found")
+	}
+	li.ListID = current.ListID
+
+	err = checkParent(tx, li)
+	if err != nil {
+		return err
+	}
+
+	li.Version, err = versionedUpdate(
+		tx, "list_item", li.ID, li.Version,
+		"parent_id = ?, category_id = ?, name = ?, notes = ?, is_checked = ?, price = ?, due_at = ?, remind_at = ?",
+		li.ParentID, li.CategoryID, li.Name, li.Notes, li.IsChecked, li.Price, li.DueAt, li.RemindAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	if current.IsChecked != li.IsChecked {
+		err = setDescendantsChecked(tx, li.ID, li.IsChecked)
+		if err != nil {
+			return err
+		}
+	}
+
+	// the item may have left its previous parent
+	if current.ParentID != nil && (li.ParentID == nil || *li.ParentID != *current.ParentID) {
+		err = rollUp(tx, current.ParentID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return rollUp(tx, &li.ID)
+}
+
+// removeItem deletes the item and returns the ID of its list
+func (s *Service) removeItem(tx *sql.Tx, ID int64) (int64, error) {
+	var listID int64
+	var parentID *int64
+	err := tx.QueryRow("select list_id, parent_id from list_item where id = ? and deleted_at is null", ID).Scan(&listID, &parentID)
+	if err != nil {
+		return 0, err
+	}
+
+	err = trashItem(tx, ID)
+	if err != nil {
+		return 0, err
+	}
+
+	return listID, rollUp(tx, parentID)
+}
+
+// GetTags return the tags of the user starting with prefix, used for autocomplete
+func (s *Service) GetTags(userID int64, prefix string) ([]*Tag, error) {
+	var result []*Tag
+
+	stmt, err := s.DB.Prepare("select id, user_id, name, created_at from tag where user_id = ? and name like ? order by name limit ?")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.Query(userID, query.EscapeLike(strings.TrimSpace(prefix))+"%", tagSuggestionsLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var t Tag
+		err := rows.Scan(&t.ID, &t.UserID, &t.Name, &t.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, &t)
+	}
+
+	return result, nil
+}
+
+// SetItemTags replace the tags of an item, tags the user never used before are created
+func (s *Service) SetItemTags(itemID int64, userID int64, tags []string) error {
+	if itemID == 0 {
+		return apperror.Validation("invalid ID")
+	}
+
+	tags = normalizeTags(tags)
+
+	err := s.validator.validateTags(tags)
+	if err != nil {
+		return err
 	}
 
 	tx, err := s.DB.Begin()
@@ -296,13 +648,312 @@ func (s *Service) RemoveItem(ID int64) error {
 		return err
 	}
 
-	_, err = tx.Exec("delete from list_item where id = ?", ID)
+	before, err := getItem(tx, itemID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = setItemTags(tx, itemID, userID, tags)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = s.recordItemRevision(tx, RevisionUpdate, itemID, before.ListID, before)
 	if err != nil {
 		tx.Rollback()
 		return err
 	}
 
-	tx.Commit()
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	s.publish(event.ItemTagsUpdated, before.ListID, map[string]interface{}{"id": itemID, "tags": tags})
 
 	return nil
 }
+
+// GetItemsByTags return the items of all lists having any, or all when matchAll
+// is set, of the given tags of the user
+func (s *Service) GetItemsByTags(userID int64, tags []string, matchAll bool) ([]*ListItem, error) {
+	var result []*ListItem
+
+	tags = normalizeTags(tags)
+	if len(tags) == 0 {
+		return result, nil
+	}
+
+	minMatches := 1
+	if matchAll {
+		minMatches = len(tags)
+	}
+
+	sql := "select " + itemColumns + `
+		from list_item as li
+		left join category c on li.category_id = c.id
+		join list_item_tag lit on lit.list_item_id = li.id
+		join tag t on t.id = lit.tag_id
+		where li.deleted_at is null and t.user_id = ? and t.name in (` + placeholders(len(tags)) + `)
+		group by li.id
+		having count(distinct t.name) >= ?
+	`
+
+	args := make([]interface{}, 0, len(tags)+2)
+	args = append(args, userID)
+	for _, t := range tags {
+		args = append(args, t)
+	}
+	args = append(args, minMatches)
+
+	rows, err := s.DB.Query(sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		li, err := scanItem(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, li)
+	}
+
+	err = loadTags(s.DB, result)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// setItemTags replaces the tags of an item, creating the ones the user never used before
+func setItemTags(tx *sql.Tx, itemID int64, userID int64, tags []string) error {
+	// the tags are part of the item, its version and updated_at change so the
+	// ETag and the delta sync see the new tags
+	_, err := tx.Exec("update list_item set version = version + 1, updated_at = now() where id = ?", itemID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("delete from list_item_tag where list_item_id = ?", itemID)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range tags {
+		_, err = tx.Exec("insert ignore into tag (user_id, name) values (?, ?)", userID, name)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(
+			"insert into list_item_tag (list_item_id, tag_id) select ?, id from tag where user_id = ? and name = ?",
+			itemID, userID, name,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadTags fills the tags of the items with a single query
+func loadTags(q querier, items []*ListItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	byID := make(map[int64]*ListItem, len(items))
+	args := make([]interface{}, 0, len(items))
+	for _, li := range items {
+		li.Tags = []string{}
+		byID[li.ID] = li
+		args = append(args, li.ID)
+	}
+
+	rows, err := q.Query(
+		"select lit.list_item_id, t.name from list_item_tag lit join tag t on t.id = lit.tag_id where lit.list_item_id in ("+placeholders(len(args))+") order by t.name",
+		args...,
+	)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var itemID int64
+		var name string
+
+		err := rows.Scan(&itemID, &name)
+		if err != nil {
+			return err
+		}
+
+		if li, ok := byID[itemID]; ok {
+			li.Tags = append(li.Tags, name)
+		}
+	}
+
+	return rows.Err()
+}
+
+// normalizeTags trims the tags and drops empty ones and duplicates, tag names
+// are case insensitive
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	result := make([]string, 0, len(tags))
+
+	for _, t := range tags {
+		t = strings.TrimSpace(t)
+		key := strings.ToLower(t)
+		if t == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, t)
+	}
+
+	return result
+}
+
+// placeholders returns n comma separated bind parameters for an in clause
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
+
+// lockVersion locks a record that is not in the trash until the end of the
+// transaction and checks it still has the version, a zero version only locks it
+func lockVersion(tx *sql.Tx, table string, ID int64, version int64) error {
+	var current int64
+	err := tx.QueryRow("select version from "+table+" where id = ? and deleted_at is null for update", ID).Scan(&current)
+	if err != nil {
+		return apperror.FromDB(err, table)
+	}
+
+	if version != 0 && version != current {
+		return &apperror.VersionConflictError{Resource: table, ID: ID, Expected: version, Current: current}
+	}
+
+	return nil
+}
+
+// versionedUpdate sets the columns of the record and increments its version. When
+// version is not zero the update only happens if it matches the current version,
+// otherwise an *apperror.VersionConflictError is returned. It returns the new version.
+func versionedUpdate(tx *sql.Tx, table string, ID int64, version int64, set string, args ...interface{}) (int64, error) {
+	query := "update " + table + " set " + set + ", version = version + 1 where id = ? and deleted_at is null"
+	args = append(args, ID)
+	if version != 0 {
+		query += " and version = ?"
+		args = append(args, version)
+	}
+
+	res, err := tx.Exec(query, args...)
+	if err != nil {
+		return 0, apperror.FromDB(err, table)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	var current int64
+	err = tx.QueryRow("select version from "+table+" where id = ? and deleted_at is null", ID).Scan(&current)
+	if err != nil {
+		return 0, apperror.FromDB(err, table)
+	}
+
+	if affected == 0 {
+		return 0, &apperror.VersionConflictError{Resource: table, ID: ID, Expected: version, Current: current}
+	}
+
+	return current, nil
+}
+
+// publish sends a change to the event publisher, when there is one
+func (s *Service) publish(eventType string, listID int64, data interface{}) {
+	if s.Events == nil {
+		return
+	}
+
+	s.Events.Publish(&event.Event{
+		Type:   eventType,
+		ListID: listID,
+		Data:   data,
+	})
+	logger.FromContext(s.ctx).Debug("list event published", "type", eventType, "list_id", listID)
+}
+
+// getList loads a list, with q being the database or a transaction
+func getList(q querier, ID int64) (*List, error) {
+	var l List
+
+	err := q.QueryRow("select "+listColumns+" from list where id = ? and deleted_at is null", ID).Scan(&l.ID, &l.Name, &l.Notes, &l.IsActive, &l.Version, &l.CreatedAt, &l.UpdatedAt, &l.DeletedAt)
+	if err != nil {
+		return nil, apperror.FromDB(err, "list")
+	}
+
+	return &l, nil
+}
+
+// getItem loads an item with its tags, with q being the database or a transaction
+func getItem(q querier, ID int64) (*ListItem, error) {
+	li, err := scanItem(q.QueryRow("select "+itemColumns+" from list_item as li left join category c on li.category_id = c.id where li.id = ? and li.deleted_at is null", ID))
+	if err != nil {
+		return nil, apperror.FromDB(err, "list item")
+	}
+
+	err = loadTags(q, []*ListItem{li})
+	if err != nil {
+		return nil, err
+	}
+
+	return li, nil
+}
+
+// getItemTree loads the items of a list nested in their parents
+func getItemTree(q querier, listID int64) ([]*ListItem, error) {
+	rows, err := q.Query("select "+itemColumns+" from list_item as li left join category c on li.category_id = c.id where li.list_id = ? and li.deleted_at is null", listID)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []*ListItem
+	for rows.Next() {
+		li, err := scanItem(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		items = append(items, li)
+	}
+	rows.Close()
+
+	err = loadTags(q, items)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildTree(items), nil
+}
+
+func scanItem(row scanner) (*ListItem, error) {
+	var li ListItem
+
+	err := row.Scan(&li.ID, &li.ListID, &li.ParentID, &li.CategoryID, &li.CategoryName, &li.Name, &li.Notes, &li.IsChecked, &li.Price, &li.DueAt, &li.RemindAt, &li.Version, &li.CreatedAt, &li.UpdatedAt, &li.DeletedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &li, nil
+}
@@ -2,9 +2,14 @@ package list_test
 
 import (
 	"database/sql"
+	"errors"
+	"net/url"
 	"testing"
+	"time"
 
+	"github.com/cristiano-pacheco/go-api/core/apperror"
 	"github.com/cristiano-pacheco/go-api/core/list"
+	"github.com/cristiano-pacheco/go-api/core/query"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/stretchr/testify/assert"
 )
@@ -86,6 +91,11 @@ func clearAndClose(db *sql.DB, t *testing.T) {
 	if err != nil {
 		tx.Rollback()
 	}
+	_, err = tx.Exec("delete from revision")
+	assert.Nil(t, err)
+	if err != nil {
+		tx.Rollback()
+	}
 	tx.Commit()
 	db.Close()
 }
@@ -120,11 +130,47 @@ func TestGetAll(t *testing.T) {
 	b2 := newData(2)
 	_ = service.Store(b1)
 	_ = service.Store(b2)
-	saved, err := service.GetAll()
+	saved, _, err := service.GetAll(nil)
 	assert.Nil(t, err)
 	assert.Equal(t, 2, len(saved))
 }
 
+func TestGetAllPaginated(t *testing.T) {
+	db := getDB(t)
+	defer clearAndClose(db, t)
+	service := list.NewService(db, &list.Validator{})
+	for i := int64(1); i <= 3; i++ {
+		_ = service.Store(newData(i))
+	}
+
+	t.Run("TestGetAllPaginated cursor", func(t *testing.T) {
+		p, err := query.Parse(url.Values{"limit": {"2"}, "sort": {"-id"}}, list.ListQuerySpec)
+		assert.Nil(t, err)
+		first, res, err := service.GetAll(p)
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(first))
+		assert.Equal(t, 3, res.Total)
+		assert.Equal(t, int64(3), first[0].ID)
+		assert.NotEqual(t, "", res.NextCursor)
+
+		p, err = query.Parse(url.Values{"limit": {"2"}, "sort": {"-id"}, "cursor": {res.NextCursor}}, list.ListQuerySpec)
+		assert.Nil(t, err)
+		second, res, err := service.GetAll(p)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(second))
+		assert.Equal(t, int64(1), second[0].ID)
+		assert.Equal(t, "", res.NextCursor)
+	})
+	t.Run("TestGetAllPaginated filtro", func(t *testing.T) {
+		p, err := query.Parse(url.Values{"is_active": {"false"}}, list.ListQuerySpec)
+		assert.Nil(t, err)
+		all, res, err := service.GetAll(p)
+		assert.Nil(t, err)
+		assert.Equal(t, 3, len(all))
+		assert.Equal(t, 3, res.Total)
+	})
+}
+
 func TestUpdate(t *testing.T) {
 	db := getDB(t)
 	defer clearAndClose(db, t)
@@ -163,7 +209,7 @@ func TestRemove(t *testing.T) {
 	_ = service.Store(b1)
 	_ = service.Store(b2)
 	service.Remove(b1.ID)
-	saved, err := service.GetAll()
+	saved, _, err := service.GetAll(nil)
 	assert.Nil(t, err)
 	assert.Equal(t, 1, len(saved))
 }
@@ -203,11 +249,37 @@ func TestGetAllItems(t *testing.T) {
 	b2 := newItemData(2)
 	_ = service.StoreItem(b1)
 	_ = service.StoreItem(b2)
-	saved, err := service.GetAllItems(1)
+	saved, _, err := service.GetAllItems(1, nil)
 	assert.Nil(t, err)
 	assert.Equal(t, 2, len(saved))
 }
 
+func TestEmbed(t *testing.T) {
+	db := getDB(t)
+	defer clearAndClose(db, t)
+	createCategoryAndList(db, t)
+	service := list.NewService(db, &list.Validator{})
+	_ = service.StoreItem(newItemData(1))
+	child := newItemData(2)
+	parentID := int64(1)
+	child.ParentID = &parentID
+	_ = service.StoreItem(child)
+
+	t.Run("TestEmbed itens e categorias", func(t *testing.T) {
+		lists, _, err := service.GetAll(nil)
+		assert.Nil(t, err)
+		err = service.Embed(lists, &list.Expand{Items: true, ItemsCategory: true})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(lists[0].Items))
+		assert.Equal(t, 1, len(lists[0].Items[0].Children))
+		assert.Equal(t, int64(1), lists[0].Items[0].Category.ID)
+	})
+	t.Run("TestEmbed expand inválido", func(t *testing.T) {
+		_, err := list.ParseExpand("items,members")
+		assert.NotNil(t, err)
+	})
+}
+
 func TestUpdateItem(t *testing.T) {
 	db := getDB(t)
 	defer clearAndClose(db, t)
@@ -229,6 +301,12 @@ func TestUpdateItem(t *testing.T) {
 		assert.Equal(t, int64(2), updated.CategoryID)
 		assert.Equal(t, int64(1), updated.ListID)
 	})
+	t.Run("TestUpdateItem outra lista", func(t *testing.T) {
+		saved, _ := service.GetItem(1)
+		saved.ListID = 2
+		err := service.UpdateItem(saved)
+		assert.Equal(t, apperror.NotFoundKind, apperror.KindOf(err))
+	})
 	t.Run("TestUpdateItem erro de validação", func(t *testing.T) {
 		e := newItemData(0)
 		err := service.UpdateItem(e)
@@ -248,7 +326,256 @@ func TestRemoveItem(t *testing.T) {
 	_ = service.StoreItem(b1)
 	_ = service.StoreItem(b2)
 	service.RemoveItem(b1.ID)
-	saved, err := service.GetAllItems(1)
+	saved, _, err := service.GetAllItems(1, nil)
 	assert.Nil(t, err)
 	assert.Equal(t, 1, len(saved))
 }
+
+func TestItemTags(t *testing.T) {
+	db := getDB(t)
+	defer clearAndClose(db, t)
+	createCategoryAndList(db, t)
+	_, err := db.Exec("insert into user (id, name, email, password) values (1, \"User\", \"user@gmail.com\", \"123\")")
+	assert.Nil(t, err)
+	defer db.Exec("delete from user")
+	service := list.NewService(db, &list.Validator{})
+	_ = service.StoreItem(newItemData(1))
+	_ = service.StoreItem(newItemData(2))
+	err = service.SetItemTags(1, 1, []string{"organic", " Organic", "for party"})
+	assert.Nil(t, err)
+	err = service.SetItemTags(2, 1, []string{"organic"})
+	assert.Nil(t, err)
+
+	saved, _ := service.GetItem(1)
+	assert.Equal(t, []string{"for party", "organic"}, saved.Tags)
+
+	tags, err := service.GetTags(1, "org")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(tags))
+	assert.Equal(t, "organic", tags[0].Name)
+
+	anyMatch, err := service.GetItemsByTags(1, []string{"organic", "for party"}, false)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(anyMatch))
+	allMatch, err := service.GetItemsByTags(1, []string{"organic", "for party", ""}, true)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(allMatch))
+	assert.Equal(t, int64(1), allMatch[0].ID)
+
+	otherUser, err := service.GetItemsByTags(2, []string{"organic"}, false)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(otherUser))
+}
+
+func TestItemTree(t *testing.T) {
+	db := getDB(t)
+	defer clearAndClose(db, t)
+	createCategoryAndList(db, t)
+	service := list.NewService(db, &list.Validator{})
+	parent := newItemData(1)
+	child := newItemData(2)
+	child.ParentID = &parent.ID
+	grandchild := newItemData(3)
+	grandchild.ParentID = &child.ID
+	assert.Nil(t, service.StoreItem(parent))
+	assert.Nil(t, service.StoreItem(child))
+	assert.Nil(t, service.StoreItem(grandchild))
+
+	t.Run("TestItemTree profundidade máxima", func(t *testing.T) {
+		tooDeep := newItemData(4)
+		tooDeep.ParentID = &grandchild.ID
+		assert.NotNil(t, service.StoreItem(tooDeep))
+	})
+
+	t.Run("TestItemTree ciclo", func(t *testing.T) {
+		moved, _ := service.GetItem(1)
+		moved.ParentID = &grandchild.ID
+		assert.NotNil(t, service.UpdateItem(moved))
+	})
+
+	t.Run("TestItemTree árvore e roll-up", func(t *testing.T) {
+		checked, _ := service.GetItem(3)
+		checked.IsChecked = true
+		assert.Nil(t, service.UpdateItem(checked))
+		all, _, err := service.GetAllItems(1, nil)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(all))
+		assert.Equal(t, true, all[0].IsChecked)
+		assert.Equal(t, int64(2), all[0].Children[0].ID)
+		assert.Equal(t, true, all[0].Children[0].IsChecked)
+		assert.Equal(t, int64(3), all[0].Children[0].Children[0].ID)
+	})
+
+	t.Run("TestItemTree remoção em cascata", func(t *testing.T) {
+		assert.Nil(t, service.RemoveItem(1))
+		all, _, err := service.GetAllItems(1, nil)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(all))
+	})
+}
+
+func TestGetStats(t *testing.T) {
+	db := getDB(t)
+	defer clearAndClose(db, t)
+	createCategoryAndList(db, t)
+	service := list.NewService(db, &list.Validator{})
+	price := 2.5
+	b1 := newItemData(1)
+	b1.Price = &price
+	b1.IsChecked = true
+	b2 := newItemData(2)
+	b2.CategoryID = 2
+	_ = service.StoreItem(b1)
+	_ = service.StoreItem(b2)
+	st, err := service.GetStats(1)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, st.TotalItems)
+	assert.Equal(t, 1, st.CheckedItems)
+	assert.Equal(t, 1, st.UncheckedItems)
+	assert.Equal(t, 0.5, st.Progress)
+	assert.Equal(t, 2.5, *st.EstimatedCost)
+	assert.Equal(t, 2, len(st.Categories))
+
+	// a parent stands for its sub-items, its price is not added to theirs
+	parentPrice, childPrice := 10.0, 1.0
+	parent := newItemData(3)
+	parent.Price = &parentPrice
+	child := newItemData(4)
+	child.ParentID = &parent.ID
+	child.Price = &childPrice
+	assert.Nil(t, service.StoreItem(parent))
+	assert.Nil(t, service.StoreItem(child))
+	st, err = service.GetStats(1)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, st.TotalItems)
+	assert.Equal(t, 3.5, *st.EstimatedCost)
+
+	all, err := service.GetAllStats()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(all))
+}
+
+func TestUpdateVersionConflict(t *testing.T) {
+	db := getDB(t)
+	defer clearAndClose(db, t)
+	service := list.NewService(db, &list.Validator{})
+	_ = service.Store(newData(1))
+	first, _ := service.Get(1)
+	second, _ := service.Get(1)
+	first.Name = "First"
+	assert.Nil(t, service.Update(first))
+	assert.Equal(t, int64(2), first.Version)
+	second.Name = "Second"
+	err := service.Update(second)
+	var conflict *apperror.VersionConflictError
+	assert.True(t, errors.As(err, &conflict))
+	assert.Equal(t, int64(2), conflict.Current)
+	saved, _ := service.Get(1)
+	assert.Equal(t, "First", saved.Name)
+}
+
+func TestHistory(t *testing.T) {
+	db := getDB(t)
+	defer clearAndClose(db, t)
+	createCategoryAndList(db, t)
+	service := list.NewService(db, &list.Validator{})
+	li := newItemData(1)
+	_ = service.StoreItem(li)
+	li.Name = "Renamed"
+	_ = service.UpdateItem(li)
+	t.Run("registra as revisoes", func(t *testing.T) {
+		history, err := service.GetHistory(1)
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(history))
+		assert.Equal(t, list.RevisionUpdate, history[0].Action)
+		assert.Equal(t, list.RevisionCreate, history[1].Action)
+		assert.Nil(t, history[1].Before)
+	})
+	t.Run("desfaz a remocao da lista", func(t *testing.T) {
+		assert.Nil(t, service.Remove(1))
+		history, _ := service.GetHistory(1)
+		assert.Equal(t, list.RevisionDelete, history[0].Action)
+		_, err := service.Restore(1, history[0].ID)
+		assert.Nil(t, err)
+		saved, _, err := service.GetAllItems(1, nil)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(saved))
+		assert.Equal(t, "Renamed", saved[0].Name)
+	})
+	t.Run("revisao inexistente", func(t *testing.T) {
+		_, err := service.Restore(1, 999999)
+		assert.Equal(t, sql.ErrNoRows, err)
+	})
+}
+
+func TestTrash(t *testing.T) {
+	db := getDB(t)
+	defer clearAndClose(db, t)
+	createCategoryAndList(db, t)
+	service := list.NewService(db, &list.Validator{})
+	parent := newItemData(1)
+	_ = service.StoreItem(parent)
+	child := newItemData(2)
+	child.ParentID = &parent.ID
+	_ = service.StoreItem(child)
+	t.Run("item vai para a lixeira com os filhos", func(t *testing.T) {
+		assert.Nil(t, service.RemoveItem(parent.ID))
+		_, err := service.GetItem(child.ID)
+		assert.Equal(t, sql.ErrNoRows, err)
+		trashed, err := service.GetItemTrash(1)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(trashed))
+		assert.Nil(t, service.RestoreItemFromTrash(parent.ID))
+		saved, _, _ := service.GetAllItems(1, nil)
+		assert.Equal(t, 1, len(saved))
+		assert.Equal(t, 1, len(saved[0].Children))
+	})
+	t.Run("lista vai para a lixeira com os itens", func(t *testing.T) {
+		assert.Nil(t, service.Remove(1))
+		_, err := service.Get(1)
+		assert.Equal(t, sql.ErrNoRows, err)
+		trashed, _ := service.GetTrash()
+		assert.Equal(t, 1, len(trashed))
+		assert.Nil(t, service.RestoreFromTrash(1))
+		saved, _, _ := service.GetAllItems(1, nil)
+		assert.Equal(t, 1, len(saved))
+	})
+	t.Run("purge apaga definitivamente", func(t *testing.T) {
+		assert.Nil(t, service.Remove(1))
+		_, err := service.Purge(time.Now().Add(time.Hour))
+		assert.Nil(t, err)
+		assert.Equal(t, sql.ErrNoRows, service.RestoreFromTrash(1))
+	})
+}
+
+func TestExecBatch(t *testing.T) {
+	db := getDB(t)
+	defer clearAndClose(db, t)
+	createCategoryAndList(db, t)
+	service := list.NewService(db, &list.Validator{})
+	existing := newItemData(0)
+	_ = service.StoreItem(existing)
+	t.Run("caminho feliz", func(t *testing.T) {
+		existing.Name = "Renamed"
+		results, err := service.ExecBatch(1, []*list.BatchOperation{
+			{Op: "create", Ref: "cake", Item: &list.ListItem{CategoryID: 1, Name: "Cake"}},
+			{Op: "create", ParentRef: "cake", Item: &list.ListItem{CategoryID: 1, Name: "Flour"}},
+			{Op: "update", ID: existing.ID, Item: existing},
+		})
+		assert.Nil(t, err)
+		assert.NotZero(t, results[0].ID)
+		saved, _, _ := service.GetAllItems(1, nil)
+		assert.Equal(t, 2, len(saved))
+	})
+	t.Run("nada e aplicado se uma operacao for invalida", func(t *testing.T) {
+		results, err := service.ExecBatch(1, []*list.BatchOperation{
+			{Op: "create", Item: &list.ListItem{CategoryID: 1, Name: "Milk"}},
+			{Op: "create", Item: &list.ListItem{CategoryID: 1}},
+		})
+		assert.Equal(t, list.ErrInvalidBatch, err)
+		assert.Empty(t, results[0].Error)
+		assert.NotEmpty(t, results[1].Error)
+		saved, _, _ := service.GetAllItems(1, nil)
+		assert.Equal(t, 2, len(saved))
+	})
+}
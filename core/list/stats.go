@@ -0,0 +1,123 @@
+package list
+
+import (
+	"database/sql"
+
+	"github.com/cristiano-pacheco/go-api/core/apperror"
+)
+
+// statsItems selects the columns of the items the statistics use with a flag
+// telling if they are leaves of the item tree
+const statsItems = `
+	select li.list_id, li.category_id, li.is_checked, li.price, li.updated_at,
+		not exists (select 1 from list_item ch where ch.parent_id = li.id and ch.deleted_at is null) as is_leaf
+	from list_item li
+	where li.deleted_at is null
+`
+
+// GetStats return the statistics of a list
+func (s *Service) GetStats(ID int64) (*Stats, error) {
+	all, err := s.stats(&ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(all) == 0 {
+		return nil, apperror.FromDB(sql.ErrNoRows, "list")
+	}
+
+	return all[0], nil
+}
+
+// GetAllStats return the statistics of every list
+func (s *Service) GetAllStats() ([]*Stats, error) {
+	return s.stats(nil)
+}
+
+// stats calculates the statistics of one list, or of all of them when ID is nil,
+// with one query for the totals and one for the categories. Only the leaves are
+// counted and priced, a parent stands for its sub-items.
+func (s *Service) stats(ID *int64) ([]*Stats, error) {
+	var result []*Stats
+
+	listFilter, args := "", []interface{}{}
+	if ID != nil {
+		listFilter, args = "and l.id = ?", []interface{}{*ID}
+	}
+
+	sql := `
+		select l.id, l.name,
+			coalesce(sum(i.is_leaf), 0),
+			coalesce(sum(i.is_leaf and i.is_checked), 0),
+			sum(case when i.is_leaf then i.price end),
+			greatest(l.updated_at, coalesce(max(i.updated_at), l.updated_at))
+		from list l
+		left join (` + statsItems + `) i on i.list_id = l.id
+		where l.deleted_at is null ` + listFilter + `
+		group by l.id, l.name, l.updated_at
+		order by l.id
+	`
+
+	rows, err := s.DB.Query(sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	byID := make(map[int64]*Stats)
+	for rows.Next() {
+		st := Stats{Categories: []*CategoryStats{}}
+		err := rows.Scan(&st.ListID, &st.Name, &st.TotalItems, &st.CheckedItems, &st.EstimatedCost, &st.LastActivityAt)
+		if err != nil {
+			return nil, err
+		}
+
+		st.UncheckedItems = st.TotalItems - st.CheckedItems
+		if st.TotalItems > 0 {
+			st.Progress = float64(st.CheckedItems) / float64(st.TotalItems)
+		}
+
+		byID[st.ListID] = &st
+		result = append(result, &st)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	categoryFilter := ""
+	if ID != nil {
+		categoryFilter = "and i.list_id = ?"
+	}
+
+	categoryRows, err := s.DB.Query(`
+		select i.list_id, c.id, c.name, count(1), coalesce(sum(i.is_checked), 0)
+		from (`+statsItems+`) i
+		join category c on c.id = i.category_id
+		where i.is_leaf `+categoryFilter+`
+		group by i.list_id, c.id, c.name
+		order by c.name
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer categoryRows.Close()
+
+	for categoryRows.Next() {
+		var listID int64
+		var cs CategoryStats
+		err := categoryRows.Scan(&listID, &cs.CategoryID, &cs.CategoryName, &cs.TotalItems, &cs.CheckedItems)
+		if err != nil {
+			return nil, err
+		}
+
+		cs.UncheckedItems = cs.TotalItems - cs.CheckedItems
+		if st, ok := byID[listID]; ok {
+			st.Categories = append(st.Categories, &cs)
+		}
+	}
+
+	return result, categoryRows.Err()
+}
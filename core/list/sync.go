@@ -0,0 +1,369 @@
+package list
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cristiano-pacheco/go-api/core/apperror"
+)
+
+// syncOverlap is how far back a new cursor starts from the time it was issued,
+// changes committed by transactions still running at that time are sent on the
+// next sync instead of being missed. Clients may receive a change twice and
+// should keep the one with the highest version.
+const syncOverlap = 5 * time.Second
+
+// sync mutation results
+const (
+	MutationApplied  = "applied"
+	MutationConflict = "conflict"
+	MutationFailed   = "failed"
+)
+
+var (
+	// ErrInvalidCursor is returned when a sync cursor was not issued by the server
+	ErrInvalidCursor = apperror.Validation("invalid sync cursor")
+	// ErrCursorExpired is returned when the removals since the cursor may have
+	// been purged already, the client must sync from scratch
+	ErrCursorExpired = errors.New("sync cursor expired, sync again without a cursor")
+)
+
+// Changes is what changed since a sync cursor. Lists and items are sent flat,
+// sub-items reference their parent through parent_id.
+type Changes struct {
+	Lists      []*List      `json:"lists"`
+	Items      []*ListItem  `json:"items"`
+	Categories []*Category  `json:"categories"`
+	Tombstones []*Tombstone `json:"tombstones"`
+	Cursor     string       `json:"cursor"`
+}
+
+// Tombstone tells a client that a list or item was removed
+type Tombstone struct {
+	EntityType string    `json:"entity_type"`
+	ID         int64     `json:"id"`
+	ListID     int64     `json:"list_id"`
+	DeletedAt  time.Time `json:"deleted_at"`
+}
+
+// Mutation is a change made by a client while offline. Records created in the
+// same batch are referenced by their client_id through list_client_id and
+// parent_client_id. Updates and deletes carry the version the client saw.
+type Mutation struct {
+	ClientID       string          `json:"client_id"`
+	Op             string          `json:"op"`
+	EntityType     string          `json:"entity_type"`
+	ID             int64           `json:"id"`
+	Version        int64           `json:"version"`
+	ListClientID   string          `json:"list_client_id"`
+	ParentClientID string          `json:"parent_client_id"`
+	Data           json.RawMessage `json:"data"`
+}
+
+// MutationResult is the outcome of one mutation, on conflicts Current holds
+// the record as it is on the server
+type MutationResult struct {
+	ClientID string      `json:"client_id"`
+	Status   string      `json:"status"`
+	ID       int64       `json:"id,omitempty"`
+	Version  int64       `json:"version,omitempty"`
+	Error    string      `json:"error,omitempty"`
+	Current  interface{} `json:"current,omitempty"`
+}
+
+// EncodeCursor turns a point in time into an opaque sync cursor
+func EncodeCursor(t time.Time) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("1:" + strconv.FormatInt(t.Unix(), 10)))
+}
+
+// DecodeCursor returns the point in time of a cursor made by EncodeCursor, an
+// empty cursor is the zero time
+func DecodeCursor(cursor string) (time.Time, error) {
+	if cursor == "" {
+		return time.Time{}, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, ErrInvalidCursor
+	}
+
+	parts := strings.SplitN(string(raw), ":", 2)
+	if len(parts) != 2 || parts[0] != "1" {
+		return time.Time{}, ErrInvalidCursor
+	}
+
+	sec, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return time.Time{}, ErrInvalidCursor
+	}
+
+	return time.Unix(sec, 0).UTC(), nil
+}
+
+// GetChanges return the lists, items and categories changed since the cursor
+// and tombstones for the removed ones. Without a cursor everything is returned.
+// Categories can only be added or renamed, so they never have tombstones.
+func (s *Service) GetChanges(cursor string) (*Changes, error) {
+	since, err := DecodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	var now time.Time
+	err = s.DB.QueryRow("select now()").Scan(&now)
+	if err != nil {
+		return nil, err
+	}
+
+	if !since.IsZero() && s.TrashRetention > 0 && since.Before(now.Add(-s.TrashRetention)) {
+		return nil, ErrCursorExpired
+	}
+
+	c := &Changes{
+		Lists:      []*List{},
+		Items:      []*ListItem{},
+		Categories: []*Category{},
+		Tombstones: []*Tombstone{},
+		Cursor:     EncodeCursor(now.Add(-syncOverlap)),
+	}
+
+	rows, err := s.DB.Query("select "+listColumns+" from list where deleted_at is null and updated_at >= ?", since)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var l List
+		err := rows.Scan(&l.ID, &l.Name, &l.Notes, &l.IsActive, &l.Version, &l.CreatedAt, &l.UpdatedAt, &l.DeletedAt)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		c.Lists = append(c.Lists, &l)
+	}
+	rows.Close()
+
+	rows, err = s.DB.Query("select "+itemColumns+" from list_item as li left join category c on li.category_id = c.id where li.deleted_at is null and li.updated_at >= ?", since)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		li, err := scanItem(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		c.Items = append(c.Items, li)
+	}
+	rows.Close()
+
+	err = loadTags(s.DB, c.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = s.DB.Query("select id, name, type, created_at, updated_at from category where updated_at >= ?", since)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var cat Category
+		err := rows.Scan(&cat.ID, &cat.Name, &cat.Type, &cat.CreatedAt, &cat.UpdatedAt)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		c.Categories = append(c.Categories, &cat)
+	}
+	rows.Close()
+
+	if since.IsZero() {
+		return c, nil
+	}
+
+	rows, err = s.DB.Query(`
+		select ?, id, id, deleted_at from list where deleted_at >= ?
+		union all
+		select ?, id, list_id, deleted_at from list_item where deleted_at >= ?
+	`, EntityList, since, EntityListItem, since)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var t Tombstone
+		err := rows.Scan(&t.EntityType, &t.ID, &t.ListID, &t.DeletedAt)
+		if err != nil {
+			return nil, err
+		}
+		c.Tombstones = append(c.Tombstones, &t)
+	}
+
+	return c, rows.Err()
+}
+
+// ApplyMutations applies the mutations in order, each one in its own
+// transaction, and returns one result per mutation. A failed mutation does
+// not stop the next ones.
+func (s *Service) ApplyMutations(mutations []*Mutation) []*MutationResult {
+	results := make([]*MutationResult, 0, len(mutations))
+	created := make(map[string]int64)
+
+	for _, m := range mutations {
+		r := s.applyMutation(m, created)
+		if r.Status == MutationApplied && m.Op == RevisionCreate && m.ClientID != "" {
+			created[m.ClientID] = r.ID
+		}
+		results = append(results, r)
+	}
+
+	return results
+}
+
+func (s *Service) applyMutation(m *Mutation, created map[string]int64) *MutationResult {
+	r := &MutationResult{ClientID: m.ClientID, ID: m.ID}
+
+	var err error
+	switch {
+	case (m.Op == RevisionUpdate || m.Op == RevisionDelete) && m.Version < 1:
+		// without the version the server changes would be silently overwritten
+		err = apperror.Validation("version is required to %s a %s", m.Op, m.EntityType)
+	case m.EntityType == EntityList && m.Op == RevisionCreate:
+		err = s.syncStoreList(m, r)
+	case m.EntityType == EntityList && m.Op == RevisionUpdate:
+		err = s.syncUpdateList(m, r)
+	case m.EntityType == EntityList && m.Op == RevisionDelete:
+		err = syncRemove(m, s.remove)
+	case m.EntityType == EntityListItem && m.Op == RevisionCreate:
+		err = s.syncStoreItem(m, r, created)
+	case m.EntityType == EntityListItem && m.Op == RevisionUpdate:
+		err = s.syncUpdateItem(m, r)
+	case m.EntityType == EntityListItem && m.Op == RevisionDelete:
+		err = syncRemove(m, s.removeItemVersion)
+	default:
+		err = apperror.Validation("unknown mutation %q on %q", m.Op, m.EntityType)
+	}
+
+	var conflict *apperror.VersionConflictError
+	switch {
+	case err == nil:
+		r.Status = MutationApplied
+	case errors.As(err, &conflict):
+		r.Status = MutationConflict
+		r.Error = err.Error()
+		if m.EntityType == EntityList {
+			if l, err := s.Get(m.ID); err == nil {
+				r.Current = l
+			}
+		} else if li, err := s.GetItem(m.ID); err == nil {
+			r.Current = li
+		}
+	default:
+		r.Status = MutationFailed
+		r.Error = err.Error()
+	}
+
+	return r
+}
+
+func (s *Service) syncStoreList(m *Mutation, r *MutationResult) error {
+	var l List
+	err := json.Unmarshal(m.Data, &l)
+	if err != nil {
+		return err
+	}
+
+	l.ID = 0
+	err = s.Store(&l)
+	if err != nil {
+		return err
+	}
+
+	r.ID, r.Version = l.ID, l.Version
+	return nil
+}
+
+func (s *Service) syncUpdateList(m *Mutation, r *MutationResult) error {
+	var l List
+	err := json.Unmarshal(m.Data, &l)
+	if err != nil {
+		return err
+	}
+
+	l.ID, l.Version = m.ID, m.Version
+	err = s.Update(&l)
+	if err != nil {
+		return err
+	}
+
+	r.Version = l.Version
+	return nil
+}
+
+func (s *Service) syncStoreItem(m *Mutation, r *MutationResult, created map[string]int64) error {
+	var li ListItem
+	err := json.Unmarshal(m.Data, &li)
+	if err != nil {
+		return err
+	}
+
+	li.ID = 0
+	if m.ListClientID != "" {
+		id, ok := created[m.ListClientID]
+		if !ok {
+			return apperror.Validation("list %q was not created in this batch", m.ListClientID)
+		}
+		li.ListID = id
+	}
+	if m.ParentClientID != "" {
+		id, ok := created[m.ParentClientID]
+		if !ok {
+			return apperror.Validation("item %q was not created in this batch", m.ParentClientID)
+		}
+		li.ParentID = &id
+	}
+
+	err = s.StoreItem(&li)
+	if err != nil {
+		return err
+	}
+
+	r.ID, r.Version = li.ID, li.Version
+	return nil
+}
+
+func (s *Service) syncUpdateItem(m *Mutation, r *MutationResult) error {
+	var li ListItem
+	err := json.Unmarshal(m.Data, &li)
+	if err != nil {
+		return err
+	}
+
+	li.ID, li.Version = m.ID, m.Version
+	err = s.UpdateItem(&li)
+	if err != nil {
+		return err
+	}
+
+	r.Version = li.Version
+	return nil
+}
+
+// syncRemove removes a record if it was not changed since the client saw it,
+// the version is checked in the transaction of the removal. Removing a record
+// that is already gone succeeds so retries are harmless.
+func syncRemove(m *Mutation, remove func(ID int64, version int64) error) error {
+	err := remove(m.ID, m.Version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,149 @@
+package list_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/cristiano-pacheco/go-api/core/list"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursor(t *testing.T) {
+	t.Run("caminho feliz", func(t *testing.T) {
+		at := time.Date(2021, 5, 1, 12, 30, 15, 0, time.UTC)
+		decoded, err := list.DecodeCursor(list.EncodeCursor(at))
+		assert.Nil(t, err)
+		assert.True(t, at.Equal(decoded))
+	})
+
+	t.Run("cursor vazio", func(t *testing.T) {
+		decoded, err := list.DecodeCursor("")
+		assert.Nil(t, err)
+		assert.True(t, decoded.IsZero())
+	})
+
+	t.Run("cursor invalido", func(t *testing.T) {
+		for _, c := range []string{"not base64!", "MTIz", "Mjox"} {
+			_, err := list.DecodeCursor(c)
+			assert.Equal(t, list.ErrInvalidCursor, err, c)
+		}
+	})
+}
+
+func TestGetChanges(t *testing.T) {
+	db := getDB(t)
+	defer clearAndClose(db, t)
+	createCategoryAndList(db, t)
+	_, err := db.Exec("insert into user (id, name, email, password) values (1, \"User\", \"user@gmail.com\", \"123\")")
+	assert.Nil(t, err)
+	defer db.Exec("delete from user")
+	service := list.NewService(db, &list.Validator{})
+	assert.Nil(t, service.StoreItem(newItemData(1)))
+	assert.Nil(t, service.StoreItem(newItemData(2)))
+
+	t.Run("Teste sincronização completa", func(t *testing.T) {
+		c, err := service.GetChanges("")
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(c.Lists))
+		assert.Equal(t, 2, len(c.Items))
+		assert.Equal(t, 2, len(c.Categories))
+		assert.Empty(t, c.Tombstones)
+		assert.NotEmpty(t, c.Cursor)
+	})
+
+	t.Run("Teste tombstones e tags desde o cursor", func(t *testing.T) {
+		c, err := service.GetChanges("")
+		assert.Nil(t, err)
+
+		assert.Nil(t, service.RemoveItem(2))
+		assert.Nil(t, service.SetItemTags(1, 1, []string{"organic"}))
+
+		changes, err := service.GetChanges(c.Cursor)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(changes.Tombstones))
+		assert.Equal(t, list.EntityListItem, changes.Tombstones[0].EntityType)
+		assert.Equal(t, int64(2), changes.Tombstones[0].ID)
+		assert.Equal(t, int64(1), changes.Tombstones[0].ListID)
+
+		assert.Equal(t, 1, len(changes.Items))
+		assert.Equal(t, int64(1), changes.Items[0].ID)
+		assert.Equal(t, int64(2), changes.Items[0].Version)
+		assert.Equal(t, []string{"organic"}, changes.Items[0].Tags)
+	})
+
+	t.Run("Teste cursor inválido", func(t *testing.T) {
+		_, err := service.GetChanges("invalid!")
+		assert.Equal(t, list.ErrInvalidCursor, err)
+	})
+}
+
+func TestApplyMutations(t *testing.T) {
+	db := getDB(t)
+	defer clearAndClose(db, t)
+	createCategoryAndList(db, t)
+	service := list.NewService(db, &list.Validator{})
+	assert.Nil(t, service.StoreItem(newItemData(1)))
+	assert.Nil(t, service.StoreItem(newItemData(2)))
+
+	data := func(v interface{}) json.RawMessage {
+		raw, _ := json.Marshal(v)
+		return raw
+	}
+
+	t.Run("Teste criação com referências do mesmo lote", func(t *testing.T) {
+		results := service.ApplyMutations([]*list.Mutation{
+			{ClientID: "l1", Op: list.RevisionCreate, EntityType: list.EntityList, Data: data(&list.List{Name: "Offline"})},
+			{ClientID: "i1", Op: list.RevisionCreate, EntityType: list.EntityListItem, ListClientID: "l1", Data: data(&list.ListItem{CategoryID: 1, Name: "Milk"})},
+			{ClientID: "i2", Op: list.RevisionCreate, EntityType: list.EntityListItem, ListClientID: "unknown", Data: data(&list.ListItem{CategoryID: 1, Name: "Eggs"})},
+		})
+		assert.Equal(t, list.MutationApplied, results[0].Status)
+		assert.Equal(t, list.MutationApplied, results[1].Status)
+		assert.Equal(t, list.MutationFailed, results[2].Status)
+
+		item, err := service.GetItem(results[1].ID)
+		assert.Nil(t, err)
+		assert.Equal(t, results[0].ID, item.ListID)
+	})
+
+	t.Run("Teste conflitos de versão", func(t *testing.T) {
+		update := newItemData(1)
+		update.Name = "Changed"
+		results := service.ApplyMutations([]*list.Mutation{
+			{ClientID: "u1", Op: list.RevisionUpdate, EntityType: list.EntityListItem, ID: 1, Version: 1, Data: data(update)},
+			{ClientID: "u2", Op: list.RevisionUpdate, EntityType: list.EntityListItem, ID: 1, Version: 1, Data: data(update)},
+			{ClientID: "d1", Op: list.RevisionDelete, EntityType: list.EntityListItem, ID: 1, Version: 1},
+		})
+		assert.Equal(t, list.MutationApplied, results[0].Status)
+		assert.Equal(t, int64(2), results[0].Version)
+		assert.Equal(t, list.MutationConflict, results[1].Status)
+		assert.Equal(t, int64(2), results[1].Current.(*list.ListItem).Version)
+		assert.Equal(t, list.MutationConflict, results[2].Status)
+
+		_, err := service.GetItem(1)
+		assert.Nil(t, err, "a conflicting removal keeps the item")
+	})
+
+	t.Run("Teste mutação sem versão", func(t *testing.T) {
+		results := service.ApplyMutations([]*list.Mutation{
+			{ClientID: "u3", Op: list.RevisionUpdate, EntityType: list.EntityListItem, ID: 1, Data: data(newItemData(1))},
+			{ClientID: "d3", Op: list.RevisionDelete, EntityType: list.EntityList, ID: 1},
+		})
+		assert.Equal(t, list.MutationFailed, results[0].Status)
+		assert.Equal(t, "version is required to update a list_item", results[0].Error)
+		assert.Equal(t, list.MutationFailed, results[1].Status)
+
+		_, err := service.Get(1)
+		assert.Nil(t, err)
+	})
+
+	t.Run("Teste remoção repetida", func(t *testing.T) {
+		remove := &list.Mutation{ClientID: "d2", Op: list.RevisionDelete, EntityType: list.EntityListItem, ID: 2, Version: 1}
+		results := service.ApplyMutations([]*list.Mutation{remove, remove})
+		assert.Equal(t, list.MutationApplied, results[0].Status)
+		assert.Equal(t, list.MutationApplied, results[1].Status)
+
+		_, err := service.GetItem(2)
+		assert.NotNil(t, err)
+	})
+}
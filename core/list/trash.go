@@ -0,0 +1,287 @@
+package list
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/cristiano-pacheco/go-api/core/apperror"
+	"github.com/cristiano-pacheco/go-api/core/event"
+)
+
+// GetTrash return the lists in the trash, the most recently removed first
+func (s *Service) GetTrash() ([]*List, error) {
+	result := []*List{}
+
+	rows, err := s.DB.Query("select " + listColumns + " from list where deleted_at is not null order by deleted_at desc")
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var l List
+		err := rows.Scan(&l.ID, &l.Name, &l.Notes, &l.IsActive, &l.Version, &l.CreatedAt, &l.UpdatedAt, &l.DeletedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, &l)
+	}
+
+	return result, rows.Err()
+}
+
+// RestoreFromTrash brings back a list removed by Remove together with the
+// items that were removed with it
+func (s *Service) RestoreFromTrash(ID int64) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	found, err := untrashList(tx, ID)
+	if err == nil && !found {
+		err = apperror.NotFound("list %d is not in the trash", ID)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = s.recordListRevision(tx, RevisionRestore, ID, nil)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	s.publish(event.ListCreated, ID, map[string]int64{"id": ID})
+
+	return nil
+}
+
+// GetItemTrash return the items of a list removed by RemoveItem, the sub-items
+// removed together with their parent are not listed on their own
+func (s *Service) GetItemTrash(listID int64) ([]*ListItem, error) {
+	result := []*ListItem{}
+
+	rows, err := s.DB.Query(`
+		select `+itemColumns+`
+		from list_item as li
+		left join category c on li.category_id = c.id
+		left join list_item p on p.id = li.parent_id
+		where li.list_id = ? and li.deleted_at is not null
+			and (p.id is null or p.deleted_at is null or p.deleted_at <> li.deleted_at)
+		order by li.deleted_at desc
+	`, listID)
+	if err != nil {
+		return nil, err
+	}
+
+	for rows.Next() {
+		li, err := scanItem(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		result = append(result, li)
+	}
+	rows.Close()
+
+	return result, loadTags(s.DB, result)
+}
+
+// RestoreItemFromTrash brings back an item removed by RemoveItem together with
+// the sub-items removed with it
+func (s *Service) RestoreItemFromTrash(ID int64) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	listID, found, err := untrashItem(tx, ID)
+	if err == nil && !found {
+		err = apperror.NotFound("list item %d is not in the trash", ID)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = s.recordItemRevision(tx, RevisionRestore, ID, listID, nil)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	s.publish(event.ItemCreated, listID, map[string]int64{"id": ID})
+
+	return nil
+}
+
+// Purge deletes for good the lists and items removed before the given time
+func (s *Service) Purge(before time.Time) (int64, error) {
+	res, err := s.DB.Exec("delete from list_item where deleted_at < ?", before)
+	if err != nil {
+		return 0, err
+	}
+
+	items, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	res, err = s.DB.Exec("delete from list where deleted_at < ?", before)
+	if err != nil {
+		return 0, err
+	}
+
+	lists, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return items + lists, nil
+}
+
+// trashList marks a list and its items as deleted, all with the same time so
+// restoring the list brings back only the items removed with it
+func trashList(tx *sql.Tx, ID int64) error {
+	now, err := dbNow(tx)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("update list set deleted_at = ? where id = ? and deleted_at is null", now, ID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("update list_item set deleted_at = ? where list_id = ? and deleted_at is null", now, ID)
+	return err
+}
+
+// trashItem marks an item and its sub-items as deleted
+func trashItem(tx *sql.Tx, ID int64) error {
+	now, err := dbNow(tx)
+	if err != nil {
+		return err
+	}
+
+	ids, err := descendantIDs(tx, ID)
+	if err != nil {
+		return err
+	}
+
+	args := []interface{}{now, ID}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	_, err = tx.Exec("update list_item set deleted_at = ? where id in ("+placeholders(len(ids)+1)+")", args...)
+	return err
+}
+
+// untrashList clears the deletion of a list and of the items removed with it,
+// found is false when the list is not in the trash
+func untrashList(tx *sql.Tx, ID int64) (bool, error) {
+	var deletedAt time.Time
+	err := tx.QueryRow("select deleted_at from list where id = ? and deleted_at is not null for update", ID).Scan(&deletedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	_, err = tx.Exec("update list set deleted_at = null, version = version + 1 where id = ?", ID)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = tx.Exec("update list_item set deleted_at = null, version = version + 1 where list_id = ? and deleted_at = ?", ID, deletedAt)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// untrashItem clears the deletion of an item and of the sub-items removed with
+// it. The list must not be in the trash, and an item whose parent is still in
+// the trash is restored at the top of the list.
+func untrashItem(tx *sql.Tx, ID int64) (int64, bool, error) {
+	var listID int64
+	var parentID *int64
+	var deletedAt time.Time
+	err := tx.QueryRow(
+		"select list_id, parent_id, deleted_at from list_item where id = ? and deleted_at is not null for update", ID,
+	).Scan(&listID, &parentID, &deletedAt)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+
+	var listDeleted bool
+	err = tx.QueryRow("select deleted_at is not null from list where id = ?", listID).Scan(&listDeleted)
+	if err != nil {
+		return 0, false, err
+	}
+	if listDeleted {
+		return 0, false, apperror.Conflict("the list %d is in the trash, restore the list first", listID)
+	}
+
+	if parentID != nil {
+		var parentDeleted bool
+		err = tx.QueryRow("select deleted_at is not null from list_item where id = ?", *parentID).Scan(&parentDeleted)
+		if err != nil {
+			return 0, false, err
+		}
+		if parentDeleted {
+			parentID = nil
+		}
+	}
+
+	ids, err := descendantIDsWhere(tx, ID, "deleted_at = ?", deletedAt)
+	if err != nil {
+		return 0, false, err
+	}
+
+	_, err = tx.Exec("update list_item set parent_id = ?, deleted_at = null, version = version + 1 where id = ?", parentID, ID)
+	if err != nil {
+		return 0, false, err
+	}
+
+	if len(ids) > 0 {
+		args := make([]interface{}, 0, len(ids))
+		for _, id := range ids {
+			args = append(args, id)
+		}
+
+		_, err = tx.Exec("update list_item set deleted_at = null, version = version + 1 where id in ("+placeholders(len(ids))+")", args...)
+		if err != nil {
+			return 0, false, err
+		}
+	}
+
+	return listID, true, rollUp(tx, parentID)
+}
+
+// dbNow returns the current time of the database, used to stamp deletions
+func dbNow(tx *sql.Tx) (time.Time, error) {
+	var now time.Time
+	err := tx.QueryRow("select now()").Scan(&now)
+	return now, err
+}
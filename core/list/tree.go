@@ -0,0 +1,229 @@
+package list
+
+import (
+	"database/sql"
+
+	"github.com/cristiano-pacheco/go-api/core/apperror"
+)
+
+// MaxItemDepth is the maximum nesting of list items, a root item has depth 1
+const MaxItemDepth = 3
+
+// buildTree nests the items under their parents and returns the root items.
+// Items whose parent is not in the slice are treated as roots.
+func buildTree(items []*ListItem) []*ListItem {
+	byID := make(map[int64]*ListItem, len(items))
+	for _, li := range items {
+		byID[li.ID] = li
+	}
+
+	roots := make([]*ListItem, 0, len(items))
+	for _, li := range items {
+		if li.ParentID != nil {
+			if parent, ok := byID[*li.ParentID]; ok {
+				parent.Children = append(parent.Children, li)
+				continue
+			}
+		}
+		roots = append(roots, li)
+	}
+
+	return roots
+}
+
+// checkParent validates that the item can be placed under parentID: the parent
+// must belong to the same list, must not be the item itself or one of its
+// descendants, and the resulting tree must not exceed MaxItemDepth
+func checkParent(tx *sql.Tx, li *ListItem) error {
+	if li.ParentID == nil {
+		return nil
+	}
+
+	var parentListID int64
+	err := tx.QueryRow("select list_id from list_item where id = ? and deleted_at is null", *li.ParentID).Scan(&parentListID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperror.Validation("invalid Parent ID")
+		}
+		return err
+	}
+
+	if parentListID != li.ListID {
+		return apperror.Validation("parent item belongs to another list")
+	}
+
+	ancestors, err := ancestorIDs(tx, *li.ParentID)
+	if err != nil {
+		return err
+	}
+
+	height := 1
+	if li.ID != 0 {
+		for _, id := range ancestors {
+			if id == li.ID {
+				return apperror.Validation("an item cannot be moved under itself or one of its sub-items")
+			}
+		}
+
+		height, err = subtreeHeight(tx, li.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(ancestors)+height > MaxItemDepth {
+		return apperror.Validation("items cannot be nested more than %d levels deep", MaxItemDepth)
+	}
+
+	return nil
+}
+
+// ancestorIDs returns the ID of the item followed by the IDs of its ancestors up to the root
+func ancestorIDs(tx *sql.Tx, ID int64) ([]int64, error) {
+	var result []int64
+
+	current := &ID
+	for current != nil && len(result) <= MaxItemDepth {
+		result = append(result, *current)
+
+		var parentID *int64
+		err := tx.QueryRow("select parent_id from list_item where id = ?", *current).Scan(&parentID)
+		if err != nil {
+			return nil, err
+		}
+		current = parentID
+	}
+
+	return result, nil
+}
+
+// descendantIDs returns the IDs of all sub-items of the item not in the trash, level by level
+func descendantIDs(tx *sql.Tx, ID int64) ([]int64, error) {
+	return descendantIDsWhere(tx, ID, "deleted_at is null")
+}
+
+// descendantIDsWhere returns the IDs of the sub-items of the item matching the
+// condition, the sub-items of an item that does not match are skipped
+func descendantIDsWhere(tx *sql.Tx, ID int64, cond string, condArgs ...interface{}) ([]int64, error) {
+	var result []int64
+
+	level := []int64{ID}
+	for len(level) > 0 {
+		args := make([]interface{}, 0, len(condArgs)+len(level))
+		args = append(args, condArgs...)
+		for _, id := range level {
+			args = append(args, id)
+		}
+
+		rows, err := tx.Query("select id from list_item where "+cond+" and parent_id in ("+placeholders(len(level))+")", args...)
+		if err != nil {
+			return nil, err
+		}
+
+		var next []int64
+		for rows.Next() {
+			var id int64
+			err := rows.Scan(&id)
+			if err != nil {
+				rows.Close()
+				return nil, err
+			}
+			next = append(next, id)
+		}
+		rows.Close()
+
+		result = append(result, next...)
+		level = next
+	}
+
+	return result, nil
+}
+
+// subtreeHeight returns the number of levels of the tree rooted at the item
+func subtreeHeight(tx *sql.Tx, ID int64) (int, error) {
+	height := 1
+
+	level := []interface{}{ID}
+	for {
+		rows, err := tx.Query("select id from list_item where deleted_at is null and parent_id in ("+placeholders(len(level))+")", level...)
+		if err != nil {
+			return 0, err
+		}
+
+		var next []interface{}
+		for rows.Next() {
+			var id int64
+			err := rows.Scan(&id)
+			if err != nil {
+				rows.Close()
+				return 0, err
+			}
+			next = append(next, id)
+		}
+		rows.Close()
+
+		if len(next) == 0 {
+			return height, nil
+		}
+
+		height++
+		level = next
+	}
+}
+
+// setDescendantsChecked propagates the checked state of an item to all its sub-items
+func setDescendantsChecked(tx *sql.Tx, ID int64, checked bool) error {
+	ids, err := descendantIDs(tx, ID)
+	if err != nil || len(ids) == 0 {
+		return err
+	}
+
+	args := make([]interface{}, 0, len(ids)+2)
+	args = append(args, checked, checked)
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	_, err = tx.Exec(
+		"update list_item set is_checked = ?, version = version + 1 where is_checked <> ? and id in ("+placeholders(len(ids))+")",
+		args...,
+	)
+	return err
+}
+
+// rollUp recalculates the checked state of the item and its ancestors: an item
+// with sub-items is checked when all of its sub-items are checked
+func rollUp(tx *sql.Tx, parentID *int64) error {
+	for parentID != nil {
+		var total, checked int
+		err := tx.QueryRow(
+			"select count(1), coalesce(sum(is_checked), 0) from list_item where parent_id = ? and deleted_at is null",
+			*parentID,
+		).Scan(&total, &checked)
+		if err != nil {
+			return err
+		}
+
+		if total > 0 {
+			_, err = tx.Exec(
+				"update list_item set is_checked = ?, version = version + 1 where id = ? and is_checked <> ?",
+				total == checked, *parentID, total == checked,
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		var next *int64
+		err = tx.QueryRow("select parent_id from list_item where id = ?", *parentID).Scan(&next)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil
+			}
+			return err
+		}
+		parentID = next
+	}
+
+	return nil
+}
@@ -1,66 +1,59 @@
 package list
 
 import (
-	"fmt"
-
+	"github.com/cristiano-pacheco/go-api/core/apperror"
 	"github.com/cristiano-pacheco/go-api/core/validator"
 )
 
-// Validator struct
-type Validator struct{}
+// maxTagLength is the size of the tag.name column
+const maxTagLength = 50
 
-func (uv *Validator) validateCreationData(l *List) error {
-	err := validator.NotEmpty("name", l.Name)
-	if err != nil {
-		return err
+func init() {
+	validator.RegisterRule("parent", validateParent)
+}
+
+// validateParent checks that an item is not its own parent
+func validateParent(c *validator.Context) error {
+	ID, _, _ := c.Sibling("ID")
+	if c.Value.Int() == 0 || c.Value.Int() == ID.Int() {
+		return apperror.InvalidField(c.Field, validator.CodeInvalid, "%s is invalid")
 	}
 
 	return nil
 }
 
+// Validator struct
+type Validator struct{}
+
+func (uv *Validator) validateCreationData(l *List) error {
+	return validator.Validate(l, validator.Create)
+}
+
 func (uv *Validator) validateUpdateData(l *List) error {
 	if l.ID == 0 {
-		return fmt.Errorf("invalid ID")
+		return apperror.New(apperror.ValidationKind, "invalid_id", "invalid ID")
 	}
 
-	err := validator.NotEmpty("name", l.Name)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return validator.Validate(l, validator.Update)
 }
 
 func (uv *Validator) validateListItemCreationData(li *ListItem) error {
-	if li.ListID == 0 {
-		return fmt.Errorf("invalid List ID")
-	}
-
-	if li.CategoryID == 0 {
-		return fmt.Errorf("invalid Category ID")
-	}
-
-	err := validator.NotEmpty("name", li.Name)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return validator.Validate(li, validator.Create)
 }
 
 func (uv *Validator) validateListItemUpdateData(li *ListItem) error {
 	if li.ID == 0 {
-		return fmt.Errorf("invalid List ID")
+		return apperror.New(apperror.ValidationKind, "invalid_id", "invalid ID")
 	}
 
-	if li.CategoryID == 0 {
-		return fmt.Errorf("invalid Category ID")
-	}
+	return validator.Validate(li, validator.Update)
+}
 
-	err := validator.NotEmpty("name", li.Name)
-	if err != nil {
-		return err
+func (uv *Validator) validateTags(tags []string) error {
+	var errs validator.Errors
+	for _, t := range tags {
+		errs.Add(validator.MaxLength("tag", t, maxTagLength))
 	}
 
-	return nil
+	return errs.Err()
 }
@@ -0,0 +1,184 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level is the severity of an entry, entries below the level of a logger are dropped
+type Level int
+
+// log levels
+const (
+	Debug Level = iota
+	Info
+	Warn
+	Error
+)
+
+var levelNames = []string{"debug", "info", "warn", "error"}
+
+func (l Level) String() string {
+	if l < Debug || l > Error {
+		return fmt.Sprintf("level(%d)", int(l))
+	}
+
+	return levelNames[l]
+}
+
+// ParseLevel returns the level of a name: debug, info, warn or error
+func ParseLevel(name string) (Level, error) {
+	for i, n := range levelNames {
+		if strings.EqualFold(n, name) {
+			return Level(i), nil
+		}
+	}
+
+	return Info, fmt.Errorf("unknown log level %q", name)
+}
+
+// Logger writes entries as JSON lines with the time, the level, the message
+// and its fields. Loggers made by With share the output of their parent.
+type Logger struct {
+	out    io.Writer
+	mu     *sync.Mutex
+	level  Level
+	fields []interface{}
+}
+
+// New returns a logger writing the entries of level or above to out
+func New(out io.Writer, level Level) *Logger {
+	return &Logger{out: out, mu: &sync.Mutex{}, level: level}
+}
+
+var std = New(os.Stdout, Info)
+
+// Default returns the logger used when a context has none
+func Default() *Logger {
+	return std
+}
+
+// SetDefault replaces the default logger, it must be called before the
+// logger is used
+func SetDefault(l *Logger) {
+	std = l
+}
+
+// Level returns the minimum level of the entries written
+func (l *Logger) Level() Level {
+	return l.level
+}
+
+// With returns a logger that adds key value pairs to every entry, e.g.
+// l.With("request_id", id)
+func (l *Logger) With(keyvals ...interface{}) *Logger {
+	child := *l
+	child.fields = append(append([]interface{}{}, l.fields...), keyvals...)
+	return &child
+}
+
+// Debug writes an entry of the debug level, keyvals are key value pairs
+func (l *Logger) Debug(msg string, keyvals ...interface{}) {
+	l.Log(Debug, msg, keyvals...)
+}
+
+// Info writes an entry of the info level
+func (l *Logger) Info(msg string, keyvals ...interface{}) {
+	l.Log(Info, msg, keyvals...)
+}
+
+// Warn writes an entry of the warn level
+func (l *Logger) Warn(msg string, keyvals ...interface{}) {
+	l.Log(Warn, msg, keyvals...)
+}
+
+// Error writes an entry of the error level
+func (l *Logger) Error(msg string, keyvals ...interface{}) {
+	l.Log(Error, msg, keyvals...)
+}
+
+// Printf writes a debug entry, so the logger can be given to libraries that
+// log with Printf
+func (l *Logger) Printf(format string, args ...interface{}) {
+	l.Log(Debug, fmt.Sprintf(format, args...))
+}
+
+// StdLogger returns a standard library logger that writes each line as an
+// entry of the level, for APIs such as http.Server.ErrorLog
+func (l *Logger) StdLogger(level Level) *log.Logger {
+	return log.New(writerFunc(func(p []byte) (int, error) {
+		l.Log(level, strings.TrimSuffix(string(p), "\n"))
+		return len(p), nil
+	}), "", 0)
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
+
+// Log writes an entry if the level is enabled
+func (l *Logger) Log(level Level, msg string, keyvals ...interface{}) {
+	if level < l.level {
+		return
+	}
+
+	entry := map[string]interface{}{
+		"time":  time.Now().UTC().Format(time.RFC3339Nano),
+		"level": level.String(),
+		"msg":   msg,
+	}
+	addFields(entry, l.fields)
+	addFields(entry, keyvals)
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		line, _ = json.Marshal(map[string]interface{}{"time": entry["time"], "level": entry["level"], "msg": msg, "log_error": err.Error()})
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.out.Write(append(line, '\n'))
+}
+
+func addFields(entry map[string]interface{}, keyvals []interface{}) {
+	for i := 0; i < len(keyvals); i += 2 {
+		key := fmt.Sprint(keyvals[i])
+		if i+1 == len(keyvals) {
+			entry[key] = nil
+			break
+		}
+
+		value := keyvals[i+1]
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		}
+		entry[key] = value
+	}
+}
+
+type contextKey struct{}
+
+// WithContext returns a context carrying a logger
+func WithContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the logger of a context, the default logger when it has none
+func FromContext(ctx context.Context) *Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(contextKey{}).(*Logger); ok {
+			return l
+		}
+	}
+
+	return std
+}
@@ -0,0 +1,66 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cristiano-pacheco/go-api/core/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := logger.New(&buf, logger.Info)
+
+	t.Run("Teste entrada JSON com campos", func(t *testing.T) {
+		buf.Reset()
+		l.With("request_id", "abc").Error("request failed", "status", 500, "error", errors.New("boom"))
+
+		var entry map[string]interface{}
+		assert.Nil(t, json.Unmarshal(buf.Bytes(), &entry))
+		assert.Equal(t, "error", entry["level"])
+		assert.Equal(t, "request failed", entry["msg"])
+		assert.Equal(t, "abc", entry["request_id"])
+		assert.Equal(t, float64(500), entry["status"])
+		assert.Equal(t, "boom", entry["error"])
+		assert.NotEmpty(t, entry["time"])
+	})
+
+	t.Run("Teste nível mínimo", func(t *testing.T) {
+		buf.Reset()
+		l.Debug("hidden")
+		l.Printf("hidden %d", 1)
+		l.Info("shown")
+		assert.Equal(t, 1, strings.Count(buf.String(), "\n"))
+	})
+
+	t.Run("Teste logger da biblioteca padrão", func(t *testing.T) {
+		buf.Reset()
+		l.With("server", "api").StdLogger(logger.Warn).Printf("http: TLS handshake error from %s", "127.0.0.1")
+
+		var entry map[string]interface{}
+		assert.Nil(t, json.Unmarshal(buf.Bytes(), &entry))
+		assert.Equal(t, "warn", entry["level"])
+		assert.Equal(t, "http: TLS handshake error from 127.0.0.1", entry["msg"])
+		assert.Equal(t, "api", entry["server"])
+	})
+
+	t.Run("Teste contexto", func(t *testing.T) {
+		assert.Equal(t, logger.Default(), logger.FromContext(context.Background()))
+		ctx := logger.WithContext(context.Background(), l)
+		assert.Equal(t, l, logger.FromContext(ctx))
+	})
+}
+
+func TestParseLevel(t *testing.T) {
+	level, err := logger.ParseLevel("DEBUG")
+	assert.Nil(t, err)
+	assert.Equal(t, logger.Debug, level)
+
+	_, err = logger.ParseLevel("verbose")
+	assert.NotNil(t, err)
+}
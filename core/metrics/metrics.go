@@ -0,0 +1,28 @@
+package metrics
+
+import (
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/common/expfmt"
+)
+
+// ContentType is the media type of the text exposition format of Prometheus
+const ContentType = string(expfmt.FmtText)
+
+// NewRegistry returns a registry with the metrics of the Go runtime, of the
+// process and of the connection pool of db. The metrics of the API are added
+// to it with promauto.With by the middlewares and services that record them.
+func NewRegistry(db *sql.DB) *prometheus.Registry {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	if db != nil {
+		reg.MustRegister(collectors.NewDBStatsCollector(db, "go_api"))
+	}
+
+	return reg
+}
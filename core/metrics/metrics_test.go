@@ -0,0 +1,38 @@
+package metrics_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/cristiano-pacheco/go-api/core/metrics"
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRegistry(t *testing.T) {
+	t.Run("Teste métricas do pool de conexões", func(t *testing.T) {
+		// sql.Open does not connect, the stats are read from the pool
+		db, err := sql.Open("mysql", "root:root@/go_api")
+		assert.Nil(t, err)
+		defer db.Close()
+
+		families, err := metrics.NewRegistry(db).Gather()
+		assert.Nil(t, err)
+
+		names := make(map[string]bool)
+		for _, f := range families {
+			names[f.GetName()] = true
+		}
+		assert.True(t, names["go_sql_open_connections"])
+		assert.True(t, names["go_sql_wait_count_total"])
+		assert.True(t, names["go_goroutines"])
+	})
+
+	t.Run("Teste sem banco de dados", func(t *testing.T) {
+		families, err := metrics.NewRegistry(nil).Gather()
+		assert.Nil(t, err)
+		for _, f := range families {
+			assert.NotContains(t, f.GetName(), "go_sql_")
+		}
+	})
+}
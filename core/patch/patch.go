@@ -0,0 +1,402 @@
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// media types of the supported patch formats
+const (
+	MergePatchType = "application/merge-patch+json"
+	JSONPatchType  = "application/json-patch+json"
+)
+
+var (
+	// ErrUnsupportedType is returned by Apply for a media type that is not a patch format
+	ErrUnsupportedType = errors.New("unsupported patch media type, use " + MergePatchType + " or " + JSONPatchType)
+	// ErrTestFailed is returned when a JSON Patch test operation does not match the document
+	ErrTestFailed = errors.New("patch test operation failed")
+)
+
+// Operation is one operation of a JSON Patch document (RFC 6902)
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// Apply applies a patch to a JSON document, the format is chosen by the media
+// type of the patch
+func Apply(mediaType string, doc []byte, p []byte) ([]byte, error) {
+	switch strings.TrimSpace(strings.SplitN(mediaType, ";", 2)[0]) {
+	case MergePatchType:
+		return MergePatch(doc, p)
+	case JSONPatchType:
+		return JSONPatch(doc, p)
+	}
+
+	return nil, ErrUnsupportedType
+}
+
+// MergePatch applies a JSON Merge Patch (RFC 7396) to a document: members of
+// the patch replace the ones of the document, null members remove them
+func MergePatch(doc []byte, p []byte) ([]byte, error) {
+	target, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	mp, err := decode(p)
+	if err != nil {
+		return nil, fmt.Errorf("invalid merge patch: %s", err)
+	}
+
+	return json.Marshal(merge(target, mp))
+}
+
+func merge(target interface{}, p interface{}) interface{} {
+	po, ok := p.(map[string]interface{})
+	if !ok {
+		return p
+	}
+
+	to, ok := target.(map[string]interface{})
+	if !ok {
+		to = make(map[string]interface{})
+	}
+
+	for k, v := range po {
+		if v == nil {
+			delete(to, k)
+			continue
+		}
+		to[k] = merge(to[k], v)
+	}
+
+	return to
+}
+
+// JSONPatch applies a JSON Patch (RFC 6902) to a document. The operations are
+// applied in order and the patch fails as a whole if one of them fails.
+func JSONPatch(doc []byte, p []byte) ([]byte, error) {
+	target, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	var ops []*Operation
+	err = json.Unmarshal(p, &ops)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON patch: %s", err)
+	}
+
+	for i, op := range ops {
+		target, err = applyOperation(target, op)
+		if err != nil {
+			if err == ErrTestFailed {
+				return nil, err
+			}
+			return nil, fmt.Errorf("operation %d (%s %s): %s", i, op.Op, op.Path, err)
+		}
+	}
+
+	return json.Marshal(target)
+}
+
+func applyOperation(doc interface{}, op *Operation) (interface{}, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, errors.New("value is required")
+		}
+
+		value, err := decode(op.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		if op.Op == "add" {
+			return add(doc, path, value)
+		}
+
+		current, err := get(doc, path)
+		if err != nil {
+			return nil, err
+		}
+
+		if op.Op == "test" {
+			if !equal(current, value) {
+				return nil, ErrTestFailed
+			}
+			return doc, nil
+		}
+
+		if len(path) == 0 {
+			return value, nil
+		}
+
+		doc, err = remove(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, value)
+	case "remove":
+		return remove(doc, path)
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := get(doc, from)
+		if err != nil {
+			return nil, err
+		}
+
+		if op.Op == "copy" {
+			// the copy must not share maps or slices with the original
+			raw, err := json.Marshal(value)
+			if err != nil {
+				return nil, err
+			}
+			value, err = decode(raw)
+			if err != nil {
+				return nil, err
+			}
+			return add(doc, path, value)
+		}
+
+		if strings.HasPrefix(op.Path, op.From+"/") {
+			return nil, errors.New("a value cannot be moved into one of its children")
+		}
+
+		doc, err = remove(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, value)
+	}
+
+	return nil, fmt.Errorf("unknown operation %q", op.Op)
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) in its reference tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+
+	if pointer[0] != '/' {
+		return nil, fmt.Errorf("invalid JSON pointer %q", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.Replace(strings.Replace(t, "~1", "/", -1), "~0", "~", -1)
+	}
+
+	return tokens, nil
+}
+
+func get(node interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			child, ok := n[token]
+			if !ok {
+				return nil, fmt.Errorf("member %q not found", token)
+			}
+			node = child
+		case []interface{}:
+			i, err := index(token, len(n)-1)
+			if err != nil {
+				return nil, err
+			}
+			node = n[i]
+		default:
+			return nil, fmt.Errorf("%q is not inside an object or array", token)
+		}
+	}
+
+	return node, nil
+}
+
+// add returns the node with value added at path, the node is changed in place
+// except for arrays that grow, so callers must use the returned node
+func add(node interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	token, rest := path[0], path[1:]
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if len(rest) == 0 {
+			n[token] = value
+			return n, nil
+		}
+
+		child, ok := n[token]
+		if !ok {
+			return nil, fmt.Errorf("member %q not found", token)
+		}
+
+		child, err := add(child, rest, value)
+		if err != nil {
+			return nil, err
+		}
+		n[token] = child
+		return n, nil
+	case []interface{}:
+		if len(rest) == 0 {
+			i := len(n)
+			if token != "-" {
+				var err error
+				i, err = index(token, len(n))
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			n = append(n, nil)
+			copy(n[i+1:], n[i:])
+			n[i] = value
+			return n, nil
+		}
+
+		i, err := index(token, len(n)-1)
+		if err != nil {
+			return nil, err
+		}
+
+		n[i], err = add(n[i], rest, value)
+		if err != nil {
+			return nil, err
+		}
+		return n, nil
+	}
+
+	return nil, fmt.Errorf("%q is not inside an object or array", token)
+}
+
+// remove returns the node without the value at path
+func remove(node interface{}, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, errors.New("the whole document cannot be removed")
+	}
+
+	token, rest := path[0], path[1:]
+	switch n := node.(type) {
+	case map[string]interface{}:
+		child, ok := n[token]
+		if !ok {
+			return nil, fmt.Errorf("member %q not found", token)
+		}
+
+		if len(rest) == 0 {
+			delete(n, token)
+			return n, nil
+		}
+
+		child, err := remove(child, rest)
+		if err != nil {
+			return nil, err
+		}
+		n[token] = child
+		return n, nil
+	case []interface{}:
+		i, err := index(token, len(n)-1)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(rest) == 0 {
+			return append(n[:i], n[i+1:]...), nil
+		}
+
+		n[i], err = remove(n[i], rest)
+		if err != nil {
+			return nil, err
+		}
+		return n, nil
+	}
+
+	return nil, fmt.Errorf("%q is not inside an object or array", token)
+}
+
+// index parses an array index token that must not be greater than max
+func index(token string, max int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+
+	if i > max {
+		return 0, fmt.Errorf("array index %d out of bounds", i)
+	}
+
+	return i, nil
+}
+
+// equal compares two decoded JSON values, numbers are compared by value
+func equal(a interface{}, b interface{}) bool {
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for k, v := range av {
+			other, ok := bv[k]
+			if !ok || !equal(v, other) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !equal(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	case json.Number:
+		bv, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		af, aerr := av.Float64()
+		bf, berr := bv.Float64()
+		return aerr == nil && berr == nil && af == bf
+	}
+
+	return a == b
+}
+
+func decode(data []byte) (interface{}, error) {
+	var v interface{}
+
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+
+	err := d.Decode(&v)
+	if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
@@ -0,0 +1,90 @@
+package patch_test
+
+import (
+	"testing"
+
+	"github.com/cristiano-pacheco/go-api/core/patch"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergePatch(t *testing.T) {
+	cases := []struct {
+		name, doc, patch, expected string
+	}{
+		{"substitui membro", `{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{"adiciona membro", `{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{"remove membro", `{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{"substitui array", `{"a":["b"]}`, `{"a":["c","d"]}`, `{"a":["c","d"]}`},
+		{"objetos aninhados", `{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{"patch que nao e objeto", `{"a":"foo"}`, `["c"]`, `["c"]`},
+		{"preserva numeros", `{"price":10.50,"id":12345678901234567}`, `{"name":"x"}`, `{"id":12345678901234567,"name":"x","price":10.50}`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			result, err := patch.MergePatch([]byte(c.doc), []byte(c.patch))
+			assert.Nil(t, err)
+			assert.JSONEq(t, c.expected, string(result))
+		})
+	}
+}
+
+func TestJSONPatch(t *testing.T) {
+	cases := []struct {
+		name, doc, patch, expected string
+	}{
+		{"add membro", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`},
+		{"add em array", `{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{"add no fim do array", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":"qux"}]`, `{"foo":["bar","qux"]}`},
+		{"remove membro", `{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{"remove de array", `{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{"replace", `{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{"move", `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`, `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{"move em array", `{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{"copy", `{"a":{"b":1}}`, `[{"op":"copy","from":"/a","path":"/c"},{"op":"replace","path":"/c/b","value":2}]`, `{"a":{"b":1},"c":{"b":2}}`},
+		{"test com numero", `{"n":1}`, `[{"op":"test","path":"/n","value":1.0},{"op":"replace","path":"/n","value":2}]`, `{"n":2}`},
+		{"ponteiro escapado", `{"a/b":1,"m~n":2}`, `[{"op":"replace","path":"/a~1b","value":3},{"op":"remove","path":"/m~0n"}]`, `{"a/b":3}`},
+		{"add nulo", `{"a":1}`, `[{"op":"add","path":"/b","value":null}]`, `{"a":1,"b":null}`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			result, err := patch.JSONPatch([]byte(c.doc), []byte(c.patch))
+			assert.Nil(t, err)
+			assert.JSONEq(t, c.expected, string(result))
+		})
+	}
+
+	t.Run("test falha", func(t *testing.T) {
+		_, err := patch.JSONPatch([]byte(`{"baz":"qux"}`), []byte(`[{"op":"test","path":"/baz","value":"bar"}]`))
+		assert.Equal(t, patch.ErrTestFailed, err)
+	})
+
+	t.Run("caminho inexistente", func(t *testing.T) {
+		_, err := patch.JSONPatch([]byte(`{"foo":"bar"}`), []byte(`[{"op":"add","path":"/baz/bat","value":"qux"}]`))
+		assert.NotNil(t, err)
+	})
+
+	t.Run("indice fora do array", func(t *testing.T) {
+		_, err := patch.JSONPatch([]byte(`{"foo":["bar"]}`), []byte(`[{"op":"add","path":"/foo/5","value":"qux"}]`))
+		assert.NotNil(t, err)
+	})
+
+	t.Run("operacao desconhecida", func(t *testing.T) {
+		_, err := patch.JSONPatch([]byte(`{}`), []byte(`[{"op":"merge","path":"/a","value":1}]`))
+		assert.NotNil(t, err)
+	})
+}
+
+func TestApply(t *testing.T) {
+	t.Run("escolhe pelo media type", func(t *testing.T) {
+		result, err := patch.Apply("application/merge-patch+json; charset=utf-8", []byte(`{"a":1}`), []byte(`{"a":2}`))
+		assert.Nil(t, err)
+		assert.JSONEq(t, `{"a":2}`, string(result))
+	})
+
+	t.Run("media type nao suportado", func(t *testing.T) {
+		_, err := patch.Apply("application/json", []byte(`{}`), []byte(`{}`))
+		assert.Equal(t, patch.ErrUnsupportedType, err)
+	})
+}
@@ -0,0 +1,99 @@
+package query
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// Fields is a sparse fieldset, the members of the response that are kept.
+// Nested members are selected with a dot, e.g. id,name,items.name keeps the id
+// and the name of a list and only the name of its items. A member selected
+// without nested fields has a nil entry and is kept whole.
+type Fields map[string]Fields
+
+// ParseFields reads a comma separated fields parameter, an empty parameter
+// returns nil which keeps every field
+func ParseFields(v string) Fields {
+	var f Fields
+
+	for _, path := range strings.Split(v, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+
+		if f == nil {
+			f = make(Fields)
+		}
+
+		node := f
+		names := strings.Split(path, ".")
+		for i, name := range names {
+			child, ok := node[name]
+			if ok && child == nil {
+				// the member is already kept whole
+				break
+			}
+			if i == len(names)-1 {
+				node[name] = nil
+				break
+			}
+			if !ok {
+				child = make(Fields)
+				node[name] = child
+			}
+			node = child
+		}
+	}
+
+	return f
+}
+
+// Select returns v with only the selected fields, slices are filtered element
+// by element. A member selected without nested fields is kept whole, with all
+// of its nested members, even when some of them are also listed.
+func (f Fields) Select(v interface{}) (interface{}, error) {
+	if len(f) == 0 {
+		return v, nil
+	}
+
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc interface{}
+	d := json.NewDecoder(bytes.NewReader(raw))
+	d.UseNumber()
+	err = d.Decode(&doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.prune(doc), nil
+}
+
+func (f Fields) prune(node interface{}) interface{} {
+	if len(f) == 0 {
+		return node
+	}
+
+	switch n := node.(type) {
+	case []interface{}:
+		for i := range n {
+			n[i] = f.prune(n[i])
+		}
+		return n
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(f))
+		for name, child := range f {
+			if value, ok := n[name]; ok {
+				result[name] = child.prune(value)
+			}
+		}
+		return result
+	}
+
+	return node
+}
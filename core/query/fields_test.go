@@ -0,0 +1,53 @@
+package query_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/cristiano-pacheco/go-api/core/query"
+	"github.com/stretchr/testify/assert"
+)
+
+type fieldsItem struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+type fieldsRow struct {
+	ID    int64         `json:"id"`
+	Name  string        `json:"name"`
+	Items []*fieldsItem `json:"items"`
+}
+
+func TestFields(t *testing.T) {
+	rows := []*fieldsRow{{ID: 1, Name: "a", Items: []*fieldsItem{{ID: 2, Name: "b"}}}}
+
+	t.Run("TestFields sem campos", func(t *testing.T) {
+		assert.Nil(t, query.ParseFields(" "))
+		v, err := query.ParseFields("").Select(rows)
+		assert.Nil(t, err)
+		assert.Equal(t, rows, v)
+	})
+	t.Run("TestFields campos aninhados", func(t *testing.T) {
+		v, err := query.ParseFields("name,items.id").Select(rows)
+		assert.Nil(t, err)
+		raw, _ := json.Marshal(v)
+		assert.JSONEq(t, `[{"name":"a","items":[{"id":2}]}]`, string(raw))
+	})
+	t.Run("TestFields membro inteiro", func(t *testing.T) {
+		v, err := query.ParseFields("items,items.id").Select(rows[0])
+		assert.Nil(t, err)
+		raw, _ := json.Marshal(v)
+		assert.JSONEq(t, `{"items":[{"id":2,"name":"b"}]}`, string(raw))
+
+		v, err = query.ParseFields("items.id,items").Select(rows[0])
+		assert.Nil(t, err)
+		raw, _ = json.Marshal(v)
+		assert.JSONEq(t, `{"items":[{"id":2,"name":"b"}]}`, string(raw))
+
+		v, err = query.ParseFields("id,items").Select(rows[0])
+		assert.Nil(t, err)
+		raw, _ = json.Marshal(v)
+		assert.JSONEq(t, `{"id":1,"items":[{"id":2,"name":"b"}]}`, string(raw))
+	})
+}
@@ -0,0 +1,353 @@
+package query
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// default and maximum page sizes
+const (
+	DefaultLimit = 50
+	MaxLimit     = 200
+)
+
+// ErrInvalidCursor is returned when a cursor was not issued for the same query
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Kind is the type of the values of a field
+type Kind int
+
+// field kinds
+const (
+	String Kind = iota
+	Int
+	Bool
+	Time
+)
+
+// Field is a field of a collection that can be sorted or filtered on
+type Field struct {
+	Column     string
+	Kind       Kind
+	Sortable   bool
+	Filterable bool
+	// Value returns the value of the field of a row, used to build cursors
+	Value func(row interface{}) interface{}
+}
+
+// Spec whitelists the fields of a collection that clients can sort and filter on
+type Spec struct {
+	Fields       map[string]*Field
+	DefaultSort  string
+	IDColumn     string
+	ID           func(row interface{}) int64
+	SearchColumn string
+}
+
+// Sort is one sort field, Desc sorts in descending order
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// Filter is an equality filter on a field
+type Filter struct {
+	Field string
+	Value interface{}
+}
+
+// Params are the pagination, sorting and filtering options of a query
+type Params struct {
+	Limit   int
+	Offset  int
+	Sort    []Sort
+	Filters []Filter
+	Search  string
+
+	spec    *Spec
+	after   []interface{}
+	afterID int64
+}
+
+// Result tells how to get the next page and how many rows match the filters
+type Result struct {
+	NextCursor string
+	Total      int
+}
+
+// Page is the response envelope of a paginated collection
+type Page struct {
+	Data       interface{} `json:"data"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+	Total      int         `json:"total"`
+}
+
+// NewPage wraps the rows of a page in the response envelope
+func NewPage(data interface{}, r *Result) *Page {
+	return &Page{Data: data, NextCursor: r.NextCursor, Total: r.Total}
+}
+
+type cursor struct {
+	Sort   string        `json:"s"`
+	Values []interface{} `json:"v"`
+	ID     int64         `json:"id"`
+}
+
+// New returns the params of the first page with the default sort
+func New(spec *Spec) *Params {
+	p := &Params{Limit: DefaultLimit, spec: spec}
+	p.Sort = parseSort(spec.DefaultSort)
+	return p
+}
+
+// Parse reads limit, offset, cursor, sort, q and the filters of the spec from
+// the query string. Sort is a comma separated list of fields, a leading minus
+// sorts in descending order, e.g. sort=-updated_at,name.
+func Parse(values url.Values, spec *Spec) (*Params, error) {
+	p := New(spec)
+
+	if v := values.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > MaxLimit {
+			return nil, fmt.Errorf("limit must be between 1 and %d", MaxLimit)
+		}
+		p.Limit = limit
+	}
+
+	if v := values.Get("sort"); v != "" {
+		p.Sort = parseSort(v)
+		for _, s := range p.Sort {
+			f, ok := spec.Fields[s.Field]
+			if !ok || !f.Sortable {
+				return nil, fmt.Errorf("cannot sort by %q", s.Field)
+			}
+		}
+	}
+
+	for name, f := range spec.Fields {
+		v := values.Get(name)
+		if v == "" || !f.Filterable {
+			continue
+		}
+
+		value, err := parseValue(f.Kind, v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %s", name, err)
+		}
+		p.Filters = append(p.Filters, Filter{Field: name, Value: value})
+	}
+
+	if spec.SearchColumn != "" {
+		p.Search = strings.TrimSpace(values.Get("q"))
+	}
+
+	c, o := values.Get("cursor"), values.Get("offset")
+	if c != "" && o != "" {
+		return nil, errors.New("cursor and offset cannot be used together")
+	}
+
+	if o != "" {
+		offset, err := strconv.Atoi(o)
+		if err != nil || offset < 0 {
+			return nil, errors.New("offset must be a positive number")
+		}
+		p.Offset = offset
+	}
+
+	if c != "" {
+		err := p.decodeCursor(c)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+func parseSort(v string) []Sort {
+	var result []Sort
+	for _, name := range strings.Split(v, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		s := Sort{Field: name}
+		if strings.HasPrefix(name, "-") {
+			s = Sort{Field: name[1:], Desc: true}
+		}
+		result = append(result, s)
+	}
+	return result
+}
+
+func (p *Params) sortKey() string {
+	parts := make([]string, len(p.Sort))
+	for i, s := range p.Sort {
+		parts[i] = s.Field
+		if s.Desc {
+			parts[i] = "-" + s.Field
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// Conditions returns the SQL conditions of the filters and the search, to be
+// joined with "and" to the conditions of the query. They are also used to
+// count the total, so they do not include the cursor.
+func (p *Params) Conditions() ([]string, []interface{}) {
+	var conds []string
+	var args []interface{}
+
+	for _, f := range p.Filters {
+		conds = append(conds, p.spec.Fields[f.Field].Column+" = ?")
+		args = append(args, f.Value)
+	}
+
+	if p.Search != "" {
+		conds = append(conds, p.spec.SearchColumn+" like ?")
+		args = append(args, "%"+EscapeLike(p.Search)+"%")
+	}
+
+	return conds, args
+}
+
+// PageConditions returns the conditions of Conditions plus the keyset
+// condition that skips the rows up to the cursor
+func (p *Params) PageConditions() ([]string, []interface{}) {
+	conds, args := p.Conditions()
+	if p.after == nil {
+		return conds, args
+	}
+
+	// (a > x) or (a = x and b > y) ... or (a = x and b = y ... and id > z)
+	var or []string
+	for i := 0; i <= len(p.Sort); i++ {
+		var and []string
+		for j := 0; j < i; j++ {
+			and = append(and, p.spec.Fields[p.Sort[j].Field].Column+" = ?")
+			args = append(args, p.after[j])
+		}
+
+		if i < len(p.Sort) {
+			op := ">"
+			if p.Sort[i].Desc {
+				op = "<"
+			}
+			and = append(and, p.spec.Fields[p.Sort[i].Field].Column+" "+op+" ?")
+			args = append(args, p.after[i])
+		} else {
+			and = append(and, p.spec.IDColumn+" > ?")
+			args = append(args, p.afterID)
+		}
+
+		or = append(or, "("+strings.Join(and, " and ")+")")
+	}
+
+	return append(conds, "("+strings.Join(or, " or ")+")"), args
+}
+
+// OrderBy returns the order by clause, the ID breaks ties so pages are stable
+func (p *Params) OrderBy() string {
+	parts := make([]string, 0, len(p.Sort)+1)
+	for _, s := range p.Sort {
+		col := p.spec.Fields[s.Field].Column
+		if s.Desc {
+			col += " desc"
+		}
+		parts = append(parts, col)
+	}
+	parts = append(parts, p.spec.IDColumn)
+
+	return " order by " + strings.Join(parts, ", ")
+}
+
+// LimitClause returns the limit clause, one row more than the page size is
+// read to know if there is a next page
+func (p *Params) LimitClause() string {
+	if p.after != nil || p.Offset == 0 {
+		return fmt.Sprintf(" limit %d", p.Limit+1)
+	}
+
+	return fmt.Sprintf(" limit %d offset %d", p.Limit+1, p.Offset)
+}
+
+// HasMore tells if a query read more rows than the page size, in that case
+// the last row must be dropped
+func (p *Params) HasMore(rows int) bool {
+	return rows > p.Limit
+}
+
+// NextCursor returns the cursor of the page that starts after the given row
+func (p *Params) NextCursor(last interface{}) string {
+	c := cursor{Sort: p.sortKey(), ID: p.spec.ID(last)}
+	for _, s := range p.Sort {
+		v := p.spec.Fields[s.Field].Value(last)
+		if t, ok := v.(time.Time); ok {
+			v = t.UTC().Format(time.RFC3339Nano)
+		}
+		c.Values = append(c.Values, v)
+	}
+
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func (p *Params) decodeCursor(v string) error {
+	raw, err := base64.RawURLEncoding.DecodeString(v)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+
+	var c cursor
+	d := json.NewDecoder(strings.NewReader(string(raw)))
+	d.UseNumber()
+	err = d.Decode(&c)
+	if err != nil || c.Sort != p.sortKey() || len(c.Values) != len(p.Sort) {
+		return ErrInvalidCursor
+	}
+
+	p.after = make([]interface{}, len(c.Values))
+	for i, s := range p.Sort {
+		value, err := parseValue(p.spec.Fields[s.Field].Kind, fmt.Sprint(c.Values[i]))
+		if err != nil {
+			return ErrInvalidCursor
+		}
+		p.after[i] = value
+	}
+	p.afterID = c.ID
+
+	return nil
+}
+
+func parseValue(k Kind, v string) (interface{}, error) {
+	switch k {
+	case Int:
+		return strconv.ParseInt(v, 10, 64)
+	case Bool:
+		return strconv.ParseBool(v)
+	case Time:
+		return time.Parse(time.RFC3339Nano, v)
+	}
+
+	return v, nil
+}
+
+// EscapeLike escapes the wildcards of a value used in a like pattern
+func EscapeLike(v string) string {
+	return strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(v)
+}
+
+// Where joins conditions into a where clause, empty when there are none
+func Where(conds []string) string {
+	if len(conds) == 0 {
+		return ""
+	}
+
+	return " where " + strings.Join(conds, " and ")
+}
@@ -0,0 +1,97 @@
+package query_test
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/cristiano-pacheco/go-api/core/query"
+	"github.com/stretchr/testify/assert"
+)
+
+type row struct {
+	ID        int64
+	Name      string
+	CreatedAt time.Time
+}
+
+var spec = &query.Spec{
+	Fields: map[string]*query.Field{
+		"name": {
+			Column: "name", Kind: query.String, Sortable: true,
+			Value: func(r interface{}) interface{} { return r.(*row).Name },
+		},
+		"created_at": {
+			Column: "created_at", Kind: query.Time, Sortable: true,
+			Value: func(r interface{}) interface{} { return r.(*row).CreatedAt },
+		},
+		"is_active": {Column: "is_active", Kind: query.Bool, Filterable: true},
+	},
+	DefaultSort:  "name",
+	IDColumn:     "id",
+	ID:           func(r interface{}) int64 { return r.(*row).ID },
+	SearchColumn: "name",
+}
+
+func TestParse(t *testing.T) {
+	t.Run("valores padrao", func(t *testing.T) {
+		p, err := query.Parse(url.Values{}, spec)
+		assert.Nil(t, err)
+		assert.Equal(t, query.DefaultLimit, p.Limit)
+		assert.Equal(t, []query.Sort{{Field: "name"}}, p.Sort)
+		assert.Equal(t, " order by name, id", p.OrderBy())
+		assert.Equal(t, " limit 51", p.LimitClause())
+	})
+
+	t.Run("filtros, busca e ordenacao", func(t *testing.T) {
+		p, err := query.Parse(url.Values{
+			"is_active": {"true"}, "q": {"50%"}, "sort": {"-created_at"}, "limit": {"10"}, "offset": {"20"},
+		}, spec)
+		assert.Nil(t, err)
+		conds, args := p.Conditions()
+		assert.Equal(t, []string{"is_active = ?", "name like ?"}, conds)
+		assert.Equal(t, []interface{}{true, "%50\\%%"}, args)
+		assert.Equal(t, " order by created_at desc, id", p.OrderBy())
+		assert.Equal(t, " limit 11 offset 20", p.LimitClause())
+	})
+
+	t.Run("valores invalidos", func(t *testing.T) {
+		for _, v := range []url.Values{
+			{"limit": {"0"}},
+			{"limit": {"1000"}},
+			{"sort": {"password"}},
+			{"is_active": {"maybe"}},
+			{"offset": {"-1"}},
+			{"offset": {"1"}, "cursor": {"abc"}},
+			{"cursor": {"not a cursor"}},
+		} {
+			_, err := query.Parse(v, spec)
+			assert.NotNil(t, err, v.Encode())
+		}
+	})
+}
+
+func TestCursor(t *testing.T) {
+	created := time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+	p, _ := query.Parse(url.Values{"sort": {"-created_at,name"}}, spec)
+	c := p.NextCursor(&row{ID: 7, Name: "Feira", CreatedAt: created})
+
+	t.Run("caminho feliz", func(t *testing.T) {
+		next, err := query.Parse(url.Values{"sort": {"-created_at,name"}, "cursor": {c}}, spec)
+		assert.Nil(t, err)
+		conds, args := next.PageConditions()
+		assert.Equal(t, []string{"((created_at < ?) or (created_at = ? and name > ?) or (created_at = ? and name = ? and id > ?))"}, conds)
+		assert.Equal(t, []interface{}{created, created, "Feira", created, "Feira", int64(7)}, args)
+		assert.Equal(t, " limit 51", next.LimitClause())
+	})
+
+	t.Run("cursor de outra ordenacao", func(t *testing.T) {
+		_, err := query.Parse(url.Values{"sort": {"name"}, "cursor": {c}}, spec)
+		assert.Equal(t, query.ErrInvalidCursor, err)
+	})
+}
+
+func TestEscapeLike(t *testing.T) {
+	assert.Equal(t, `50\% off\_now \\o/`, query.EscapeLike(`50% off_now \o/`))
+	assert.Equal(t, "milk", query.EscapeLike("milk"))
+}
@@ -0,0 +1,25 @@
+package recurrence
+
+import "time"
+
+// Recurrence struct, generates new instances of the template list following Rule
+type Recurrence struct {
+	ID        int64      `json:"id"`
+	ListID    int64      `json:"list_id"`
+	Rule      string     `json:"rule"`
+	StartsAt  time.Time  `json:"starts_at"`
+	NextRunAt time.Time  `json:"next_run_at"`
+	LastRunAt *time.Time `json:"last_run_at"`
+	IsActive  bool       `json:"is_active"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// Run records a list generated for one occurrence of a recurrence
+type Run struct {
+	ID           int64     `json:"id"`
+	RecurrenceID int64     `json:"recurrence_id"`
+	ScheduledFor time.Time `json:"scheduled_for"`
+	ListID       *int64    `json:"list_id"`
+	CreatedAt    time.Time `json:"created_at"`
+}
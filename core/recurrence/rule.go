@@ -0,0 +1,230 @@
+package recurrence
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cristiano-pacheco/go-api/core/apperror"
+)
+
+// Frequency of a recurrence rule
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+)
+
+// maxLookahead bounds the number of days Next inspects before giving up
+const maxLookahead = 5 * 366
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+var weekdayCodes = []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// Rule is the parsed form of a RRULE-style string such as FREQ=WEEKLY;BYDAY=SA
+type Rule struct {
+	Frequency  Frequency
+	Interval   int
+	ByDay      []time.Weekday
+	ByMonthDay int
+}
+
+// ParseRule parses the supported subset of RFC 5545 RRULE: FREQ, INTERVAL, BYDAY and BYMONTHDAY
+func ParseRule(s string) (*Rule, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	if s == "" {
+		return nil, apperror.Validation("rule cannot be empty")
+	}
+
+	r := &Rule{Interval: 1}
+
+	for _, part := range strings.Split(s, ";") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return nil, apperror.Validation("invalid rule part %q", part)
+		}
+
+		key, value := strings.ToUpper(kv[0]), strings.ToUpper(kv[1])
+
+		switch key {
+		case "FREQ":
+			switch Frequency(value) {
+			case Daily, Weekly, Monthly:
+				r.Frequency = Frequency(value)
+			default:
+				return nil, apperror.Validation("unsupported frequency %q", value)
+			}
+		case "INTERVAL":
+			i, err := strconv.Atoi(value)
+			if err != nil || i < 1 {
+				return nil, apperror.Validation("invalid interval %q", value)
+			}
+			r.Interval = i
+		case "BYDAY":
+			for _, d := range strings.Split(value, ",") {
+				wd, ok := weekdays[d]
+				if !ok {
+					return nil, apperror.Validation("invalid weekday %q", d)
+				}
+				r.ByDay = append(r.ByDay, wd)
+			}
+		case "BYMONTHDAY":
+			d, err := strconv.Atoi(value)
+			if err != nil || d < 1 || d > 31 {
+				return nil, apperror.Validation("invalid month day %q", value)
+			}
+			r.ByMonthDay = d
+		default:
+			return nil, apperror.Validation("unsupported rule part %q", key)
+		}
+	}
+
+	if r.Frequency == "" {
+		return nil, apperror.Validation("rule must define FREQ")
+	}
+
+	if len(r.ByDay) > 0 && r.Frequency != Weekly {
+		return nil, apperror.Validation("BYDAY is only supported with FREQ=WEEKLY")
+	}
+
+	if r.ByMonthDay > 0 && r.Frequency != Monthly {
+		return nil, apperror.Validation("BYMONTHDAY is only supported with FREQ=MONTHLY")
+	}
+
+	return r, nil
+}
+
+// String formats the rule back to its RRULE representation
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Frequency)}
+
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, d := range r.ByDay {
+			days[i] = weekdayCodes[d]
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+
+	if r.ByMonthDay > 0 {
+		parts = append(parts, "BYMONTHDAY="+strconv.Itoa(r.ByMonthDay))
+	}
+
+	return strings.Join(parts, ";")
+}
+
+// Next returns the first occurrence strictly after the given time. Occurrences
+// happen at the time of day of start and never before start. A zero time is
+// returned when no occurrence is found within the lookahead window.
+func (r *Rule) Next(start, after time.Time) time.Time {
+	from := start
+	if after.After(from) {
+		from = after.In(start.Location())
+	}
+
+	day := civilDate(from)
+
+	for i := 0; i <= maxLookahead; i++ {
+		d := day.AddDate(0, 0, i)
+		t := time.Date(d.Year(), d.Month(), d.Day(), start.Hour(), start.Minute(), start.Second(), 0, start.Location())
+
+		if t.Before(start) || !t.After(after) {
+			continue
+		}
+
+		if r.matches(start, t) {
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+// CheckGaps returns an error when, within a horizon of several lookahead
+// windows from start, two occurrences are further apart than Next can see.
+// Such rules would stop being scheduled, e.g. FREQ=DAILY;INTERVAL=5000 or
+// FREQ=MONTHLY;INTERVAL=12;BYMONTHDAY=31 starting in a 30 day month.
+func (r *Rule) CheckGaps(start time.Time) error {
+	end := start.AddDate(0, 0, 3*maxLookahead)
+
+	t := start.Add(-time.Second)
+	for t.Before(end) {
+		t = r.Next(start, t)
+		if t.IsZero() {
+			return apperror.Validation("the rule has no occurrence for more than %d days", maxLookahead)
+		}
+	}
+
+	return nil
+}
+
+func (r *Rule) matches(start, t time.Time) bool {
+	switch r.Frequency {
+	case Daily:
+		return daysBetween(start, t)%r.Interval == 0
+	case Weekly:
+		byDay := r.ByDay
+		if len(byDay) == 0 {
+			byDay = []time.Weekday{start.Weekday()}
+		}
+
+		if !containsWeekday(byDay, t.Weekday()) {
+			return false
+		}
+
+		weeks := daysBetween(weekStart(start), weekStart(t)) / 7
+		return weeks%r.Interval == 0
+	case Monthly:
+		monthDay := r.ByMonthDay
+		if monthDay == 0 {
+			monthDay = start.Day()
+		}
+
+		if t.Day() != monthDay {
+			return false
+		}
+
+		months := (t.Year()-start.Year())*12 + int(t.Month()) - int(start.Month())
+		return months%r.Interval == 0
+	}
+
+	return false
+}
+
+func civilDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func daysBetween(a, b time.Time) int {
+	return int(civilDate(b).Sub(civilDate(a)).Hours() / 24)
+}
+
+// weekStart returns the monday of the week of t
+func weekStart(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return civilDate(t).AddDate(0, 0, -offset)
+}
+
+func containsWeekday(days []time.Weekday, d time.Weekday) bool {
+	for _, wd := range days {
+		if wd == d {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package recurrence_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cristiano-pacheco/go-api/core/recurrence"
+	"github.com/stretchr/testify/assert"
+)
+
+func date(s string) time.Time {
+	t, _ := time.Parse("2006-01-02 15:04", s)
+	return t
+}
+
+func TestParseRule(t *testing.T) {
+	r, err := recurrence.ParseRule("RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=SA,SU")
+	assert.Nil(t, err)
+	assert.Equal(t, recurrence.Weekly, r.Frequency)
+	assert.Equal(t, 2, r.Interval)
+	assert.Equal(t, []time.Weekday{time.Saturday, time.Sunday}, r.ByDay)
+	assert.Equal(t, "FREQ=WEEKLY;INTERVAL=2;BYDAY=SA,SU", r.String())
+
+	_, err = recurrence.ParseRule("")
+	assert.NotNil(t, err)
+	_, err = recurrence.ParseRule("FREQ=YEARLY")
+	assert.NotNil(t, err)
+	_, err = recurrence.ParseRule("FREQ=DAILY;BYDAY=SA")
+	assert.NotNil(t, err)
+	_, err = recurrence.ParseRule("INTERVAL=2")
+	assert.NotNil(t, err)
+}
+
+func TestNextDaily(t *testing.T) {
+	r, _ := recurrence.ParseRule("FREQ=DAILY;INTERVAL=3")
+	start := date("2021-04-01 08:00")
+	assert.Equal(t, start, r.Next(start, start.Add(-time.Second)))
+	assert.Equal(t, date("2021-04-04 08:00"), r.Next(start, start))
+	assert.Equal(t, date("2021-04-07 08:00"), r.Next(start, date("2021-04-05 12:00")))
+}
+
+func TestNextWeekly(t *testing.T) {
+	// 2021-04-03 is a saturday
+	r, _ := recurrence.ParseRule("FREQ=WEEKLY;BYDAY=SA")
+	start := date("2021-04-01 09:00")
+	assert.Equal(t, date("2021-04-03 09:00"), r.Next(start, start))
+	assert.Equal(t, date("2021-04-10 09:00"), r.Next(start, date("2021-04-03 09:00")))
+
+	r, _ = recurrence.ParseRule("FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,SA")
+	assert.Equal(t, date("2021-04-03 09:00"), r.Next(start, start))
+	assert.Equal(t, date("2021-04-12 09:00"), r.Next(start, date("2021-04-03 09:00")))
+}
+
+func TestNextMonthly(t *testing.T) {
+	r, _ := recurrence.ParseRule("FREQ=MONTHLY;BYMONTHDAY=31")
+	start := date("2021-01-01 10:00")
+	assert.Equal(t, date("2021-01-31 10:00"), r.Next(start, start))
+	// february and april have no day 31
+	assert.Equal(t, date("2021-03-31 10:00"), r.Next(start, date("2021-01-31 10:00")))
+	assert.Equal(t, date("2021-05-31 10:00"), r.Next(start, date("2021-03-31 10:00")))
+}
+
+func TestCheckGaps(t *testing.T) {
+	cases := map[string]bool{
+		"FREQ=DAILY":                             true,
+		"FREQ=WEEKLY;INTERVAL=2;BYDAY=SA,SU":     true,
+		"FREQ=MONTHLY;BYMONTHDAY=31":             true,
+		"FREQ=MONTHLY;INTERVAL=12;BYMONTHDAY=29": true,
+		"FREQ=DAILY;INTERVAL=5000":               false,
+		"FREQ=WEEKLY;INTERVAL=300":               false,
+		"FREQ=MONTHLY;INTERVAL=12;BYMONTHDAY=31": false,
+	}
+
+	start := date("2021-04-01 08:00")
+	for rule, valid := range cases {
+		r, err := recurrence.ParseRule(rule)
+		assert.Nil(t, err)
+		assert.Equal(t, valid, r.CheckGaps(start) == nil, rule)
+	}
+}
@@ -0,0 +1,407 @@
+package recurrence
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/cristiano-pacheco/go-api/core/apperror"
+	"github.com/cristiano-pacheco/go-api/core/validator"
+)
+
+// mysqlDuplicateEntry is the MySQL error number for unique key violations
+const mysqlDuplicateEntry = 1062
+
+// UseCase Define the interface with functions that will be used
+type UseCase interface {
+	GetAll() ([]*Recurrence, error)
+	Get(ID int64) (*Recurrence, error)
+	Store(r *Recurrence) error
+	Update(r *Recurrence) error
+	Remove(ID int64) error
+	GetAllRuns(recurrenceID int64) ([]*Run, error)
+	RunDue(now time.Time) (int, error)
+}
+
+// Service define the struct for service
+type Service struct {
+	DB        *sql.DB
+	validator *Validator
+}
+
+// NewService constructor
+func NewService(db *sql.DB, v *Validator) *Service {
+	return &Service{
+		DB:        db,
+		validator: v,
+	}
+}
+
+// GetAll return all records from the database
+func (s *Service) GetAll() ([]*Recurrence, error) {
+	var result []*Recurrence
+
+	rows, err := s.DB.Query("select id, list_id, rule, starts_at, next_run_at, last_run_at, is_active, created_at, updated_at from recurrence")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var r Recurrence
+		err := rows.Scan(&r.ID, &r.ListID, &r.Rule, &r.StartsAt, &r.NextRunAt, &r.LastRunAt, &r.IsActive, &r.CreatedAt, &r.UpdatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, &r)
+	}
+
+	return result, nil
+}
+
+// Get the record from the database
+func (s *Service) Get(ID int64) (*Recurrence, error) {
+	var r Recurrence
+
+	stmt, err := s.DB.Prepare("select id, list_id, rule, starts_at, next_run_at, last_run_at, is_active, created_at, updated_at from recurrence where id = ?")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	err = stmt.QueryRow(ID).Scan(&r.ID, &r.ListID, &r.Rule, &r.StartsAt, &r.NextRunAt, &r.LastRunAt, &r.IsActive, &r.CreatedAt, &r.UpdatedAt)
+
+	if err != nil {
+		return nil, apperror.FromDB(err, "recurrence")
+	}
+
+	return &r, nil
+}
+
+// Store a record in the database
+func (s *Service) Store(r *Recurrence) error {
+	err := s.validator.validateCreationData(r)
+	if err != nil {
+		return err
+	}
+
+	rule, _ := ParseRule(r.Rule)
+	r.Rule = rule.String()
+	r.StartsAt = r.StartsAt.UTC()
+	r.NextRunAt = rule.Next(r.StartsAt, r.StartsAt.Add(-time.Second))
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare("insert into recurrence (list_id, rule, starts_at, next_run_at, is_active) values (?, ?, ?, ?, ?)")
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	defer stmt.Close()
+
+	res, err := stmt.Exec(r.ListID, r.Rule, r.StartsAt, r.NextRunAt, r.IsActive)
+	if err != nil {
+		tx.Rollback()
+		return apperror.FromDB(err, "recurrence")
+	}
+
+	r.ID, err = res.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Update an record in the database, the next run is recalculated from the new rule
+func (s *Service) Update(r *Recurrence) error {
+	err := s.validator.validateUpdateData(r)
+	if err != nil {
+		return err
+	}
+
+	rule, _ := ParseRule(r.Rule)
+	r.Rule = rule.String()
+	r.StartsAt = r.StartsAt.UTC()
+	r.NextRunAt = rule.Next(r.StartsAt, time.Now().UTC())
+	if r.NextRunAt.IsZero() {
+		return apperror.InvalidField("rule", validator.CodeInvalid, "%s has no occurrence after now")
+	}
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare("update recurrence set list_id = ?, rule = ?, starts_at = ?, next_run_at = ?, is_active = ? where id = ?")
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.Exec(r.ListID, r.Rule, r.StartsAt, r.NextRunAt, r.IsActive, r.ID)
+	if err != nil {
+		tx.Rollback()
+		return apperror.FromDB(err, "recurrence")
+	}
+
+	return tx.Commit()
+}
+
+// Remove an record from the database
+func (s *Service) Remove(ID int64) error {
+	if ID == 0 {
+		return apperror.Validation("invalid ID")
+	}
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("delete from recurrence where id = ?", ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetAllRuns return the runs of a recurrence, newest first
+func (s *Service) GetAllRuns(recurrenceID int64) ([]*Run, error) {
+	var result []*Run
+
+	stmt, err := s.DB.Prepare("select id, recurrence_id, scheduled_for, list_id, created_at from recurrence_run where recurrence_id = ? order by scheduled_for desc")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.Query(recurrenceID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var r Run
+		err := rows.Scan(&r.ID, &r.RecurrenceID, &r.ScheduledFor, &r.ListID, &r.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, &r)
+	}
+
+	return result, nil
+}
+
+// RunDue creates a list for every active recurrence whose next run is due and
+// returns how many lists were created. Missed occurrences are not backfilled:
+// after a run the next occurrence is calculated from now. A failing recurrence
+// does not block the others, the first error is returned.
+func (s *Service) RunDue(now time.Time) (int, error) {
+	stmt, err := s.DB.Prepare("select r.id, r.list_id, r.rule, r.starts_at, r.next_run_at from recurrence r join list l on l.id = r.list_id and l.deleted_at is null where r.is_active = 1 and r.next_run_at <= ?")
+	if err != nil {
+		return 0, err
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.Query(now)
+	if err != nil {
+		return 0, err
+	}
+
+	var due []*Recurrence
+	for rows.Next() {
+		var r Recurrence
+		err := rows.Scan(&r.ID, &r.ListID, &r.Rule, &r.StartsAt, &r.NextRunAt)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		due = append(due, &r)
+	}
+	rows.Close()
+
+	created := 0
+	var firstErr error
+	for _, r := range due {
+		ok, err := s.run(r, now)
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("recurrence %d: %w", r.ID, err)
+		}
+		if ok {
+			created++
+		}
+	}
+
+	return created, firstErr
+}
+
+// run generates the list for the occurrence scheduled at r.NextRunAt. The unique
+// key on recurrence_run (recurrence_id, scheduled_for) makes it idempotent: if the
+// occurrence was already generated, e.g. before a restart, only the schedule advances.
+func (s *Service) run(r *Recurrence, now time.Time) (bool, error) {
+	rule, err := ParseRule(r.Rule)
+	if err != nil {
+		return false, err
+	}
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return false, err
+	}
+
+	created := true
+
+	res, err := tx.Exec("insert into recurrence_run (recurrence_id, scheduled_for) values (?, ?)", r.ID, r.NextRunAt)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if !errors.As(err, &mysqlErr) || mysqlErr.Number != mysqlDuplicateEntry {
+			tx.Rollback()
+			return false, err
+		}
+		created = false
+	}
+
+	if created {
+		err = s.instantiate(tx, res, r)
+		if err != nil {
+			tx.Rollback()
+			return false, err
+		}
+	}
+
+	// a rule without further occurrences keeps its last schedule and is
+	// deactivated, next_run_at cannot hold the zero time
+	next := rule.Next(r.StartsAt, now)
+	if next.IsZero() {
+		_, err = tx.Exec("update recurrence set is_active = 0, last_run_at = ? where id = ?", now, r.ID)
+	} else {
+		_, err = tx.Exec("update recurrence set next_run_at = ?, last_run_at = ? where id = ?", next, now, r.ID)
+	}
+	if err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	return created, tx.Commit()
+}
+
+// instantiate copies the template list and its items into a new list
+func (s *Service) instantiate(tx *sql.Tx, runResult sql.Result, r *Recurrence) error {
+	runID, err := runResult.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	res, err := tx.Exec(
+		"insert into list (name, notes, is_active) select concat(name, ' - ', ?), notes, 1 from list where id = ?",
+		r.NextRunAt.Format("2006-01-02"), r.ListID,
+	)
+	if err != nil {
+		return err
+	}
+
+	listID, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	err = copyItems(tx, r.ListID, listID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("update recurrence_run set list_id = ? where id = ?", listID, runID)
+	return err
+}
+
+// copyItems copies the items of a list into another one, keeping the nesting of sub-items
+func copyItems(tx *sql.Tx, fromListID, toListID int64) error {
+	type templateItem struct {
+		id         int64
+		parentID   *int64
+		categoryID int64
+		name       string
+		notes      string
+	}
+
+	rows, err := tx.Query("select id, parent_id, category_id, name, notes from list_item where list_id = ? and deleted_at is null", fromListID)
+	if err != nil {
+		return err
+	}
+
+	var pending []*templateItem
+	for rows.Next() {
+		var ti templateItem
+		err := rows.Scan(&ti.id, &ti.parentID, &ti.categoryID, &ti.name, &ti.notes)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		pending = append(pending, &ti)
+	}
+	rows.Close()
+
+	// parents are inserted before their children, newIDs maps template IDs to copies
+	newIDs := make(map[int64]int64, len(pending))
+	for len(pending) > 0 {
+		var next []*templateItem
+
+		for _, ti := range pending {
+			var parentID *int64
+			if ti.parentID != nil {
+				id, ok := newIDs[*ti.parentID]
+				if !ok {
+					next = append(next, ti)
+					continue
+				}
+				parentID = &id
+			}
+
+			res, err := tx.Exec(
+				"insert into list_item (list_id, parent_id, category_id, name, notes) values (?, ?, ?, ?, ?)",
+				toListID, parentID, ti.categoryID, ti.name, ti.notes,
+			)
+			if err != nil {
+				return err
+			}
+
+			newIDs[ti.id], err = res.LastInsertId()
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(next) == len(pending) {
+			return fmt.Errorf("list %d has items whose parent is missing", fromListID)
+		}
+		pending = next
+	}
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package recurrence
+
+import (
+	"github.com/cristiano-pacheco/go-api/core/apperror"
+	"github.com/cristiano-pacheco/go-api/core/validator"
+)
+
+// Validator struct
+type Validator struct{}
+
+func (uv *Validator) validateCreationData(r *Recurrence) error {
+	var errs validator.Errors
+	if r.ListID == 0 {
+		errs.Field("list_id", validator.CodeRequired, "%s cannot be empty")
+	}
+
+	rule, err := ParseRule(r.Rule)
+	if err != nil {
+		errs = append(errs, apperror.NewFieldError("rule", validator.CodeInvalid, "invalid_rule", "%s is invalid: %s", err.Error()))
+	}
+
+	if r.StartsAt.IsZero() {
+		errs.Field("starts_at", validator.CodeRequired, "%s cannot be empty")
+	} else if rule != nil {
+		err = rule.CheckGaps(r.StartsAt.UTC())
+		if err != nil {
+			errs = append(errs, apperror.NewFieldError("rule", validator.CodeInvalid, "invalid_rule", "%s is invalid: %s", err.Error()))
+		}
+	}
+
+	return errs.Err()
+}
+
+func (uv *Validator) validateUpdateData(r *Recurrence) error {
+	if r.ID == 0 {
+		return apperror.New(apperror.ValidationKind, "invalid_id", "invalid ID")
+	}
+
+	return uv.validateCreationData(r)
+}
@@ -0,0 +1,38 @@
+package reminder
+
+import "time"
+
+// Reminder carries the data of a list item whose remind_at has been reached
+type Reminder struct {
+	ItemID   int64      `json:"item_id"`
+	ItemName string     `json:"item_name"`
+	ListID   int64      `json:"list_id"`
+	ListName string     `json:"list_name"`
+	DueAt    *time.Time `json:"due_at"`
+	RemindAt time.Time  `json:"remind_at"`
+
+	// deliveryID is the delivery of a previous attempt, when there is one
+	deliveryID *int64
+}
+
+// statuses of a delivery
+const (
+	StatusPending = "pending"
+	StatusSent    = "sent"
+	StatusFailed  = "failed"
+)
+
+// Delivery records the sending of the reminder of an item. It is pending while
+// a dispatcher notifies it, failed ones are retried with a growing delay until
+// Attempts reaches the limit.
+type Delivery struct {
+	ID          int64      `json:"id"`
+	ListItemID  int64      `json:"list_item_id"`
+	RemindAt    time.Time  `json:"remind_at"`
+	Notifier    string     `json:"notifier"`
+	Status      string     `json:"status"`
+	Error       string     `json:"error,omitempty"`
+	Attempts    int        `json:"attempts"`
+	ClaimedAt   time.Time  `json:"claimed_at"`
+	DeliveredAt *time.Time `json:"delivered_at"`
+}
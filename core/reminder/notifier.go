@@ -0,0 +1,91 @@
+package reminder
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cristiano-pacheco/go-api/core/logger"
+)
+
+// Notifier sends a reminder through some channel
+type Notifier interface {
+	Name() string
+	Notify(r *Reminder) error
+}
+
+// LogNotifier writes the reminders to a logger
+type LogNotifier struct {
+	Logger *logger.Logger
+}
+
+// Name of the notifier
+func (n *LogNotifier) Name() string {
+	return "log"
+}
+
+// Notify logs the reminder
+func (n *LogNotifier) Notify(r *Reminder) error {
+	n.Logger.Info("reminder", "item_id", r.ItemID, "item_name", r.ItemName, "list_id", r.ListID, "list_name", r.ListName)
+	return nil
+}
+
+// OutboxNotifier stores the reminders as emails in the email_outbox table, to be
+// sent by the mail relay. Lists have no owner, so every reminder is addressed
+// to the single recipient To, e.g. a shared household address.
+type OutboxNotifier struct {
+	DB *sql.DB
+	To string
+}
+
+// Name of the notifier
+func (n *OutboxNotifier) Name() string {
+	return "outbox"
+}
+
+// Notify writes the reminder email to the outbox
+func (n *OutboxNotifier) Notify(r *Reminder) error {
+	subject := fmt.Sprintf("Reminder: %s", r.ItemName)
+	body := fmt.Sprintf("%s from the list %s", r.ItemName, r.ListName)
+	if r.DueAt != nil {
+		body += fmt.Sprintf(" is due at %s", r.DueAt.Format(time.RFC1123))
+	}
+
+	_, err := n.DB.Exec("insert into email_outbox (recipient, subject, body) values (?, ?, ?)", n.To, subject, body)
+	return err
+}
+
+// WebhookNotifier posts the reminders as JSON to an URL
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+// Name of the notifier
+func (n *WebhookNotifier) Name() string {
+	return "webhook"
+}
+
+// Notify posts the reminder, any non 2xx response is an error
+func (n *WebhookNotifier) Notify(r *Reminder) error {
+	payload, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	res, err := n.Client.Post(n.URL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %d", res.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package reminder_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cristiano-pacheco/go-api/core/logger"
+	"github.com/cristiano-pacheco/go-api/core/reminder"
+	"github.com/stretchr/testify/assert"
+)
+
+func newReminder() *reminder.Reminder {
+	return &reminder.Reminder{
+		ItemID:   1,
+		ItemName: "Milk",
+		ListID:   2,
+		ListName: "Groceries",
+		RemindAt: time.Date(2021, 4, 3, 9, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestLogNotifier(t *testing.T) {
+	var buf bytes.Buffer
+	n := &reminder.LogNotifier{Logger: logger.New(&buf, logger.Info)}
+	err := n.Notify(newReminder())
+	assert.Nil(t, err)
+
+	var entry map[string]interface{}
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "reminder", entry["msg"])
+	assert.Equal(t, float64(1), entry["item_id"])
+	assert.Equal(t, "Milk", entry["item_name"])
+	assert.Equal(t, float64(2), entry["list_id"])
+	assert.Equal(t, "Groceries", entry["list_name"])
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var received reminder.Reminder
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	n := &reminder.WebhookNotifier{URL: srv.URL, Client: srv.Client()}
+	err := n.Notify(newReminder())
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), received.ItemID)
+	assert.Equal(t, "Groceries", received.ListName)
+}
+
+func TestWebhookNotifierError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	n := &reminder.WebhookNotifier{URL: srv.URL, Client: srv.Client()}
+	err := n.Notify(newReminder())
+	assert.Equal(t, "webhook responded with status 502", err.Error())
+}
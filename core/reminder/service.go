@@ -0,0 +1,202 @@
+package reminder
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// mysqlDuplicateEntry is the MySQL error number for unique key violations
+const mysqlDuplicateEntry = 1062
+
+// claimTimeout is how long a pending delivery stays claimed, a dispatcher that
+// stopped while notifying leaves it pending and another one retries it after
+const claimTimeout = 5 * time.Minute
+
+// maxAttempts is how many times a reminder is notified before its delivery
+// stays failed
+const maxAttempts = 5
+
+// retryDelay is the wait before the first retry of a delivery, it doubles on
+// every attempt
+const retryDelay = time.Minute
+
+// maxErrorLength is the size, in characters, of the error column of
+// reminder_delivery
+const maxErrorLength = 500
+
+// retryCondition selects the deliveries that can be claimed again: failed ones
+// whose backoff is over and pending ones abandoned by a dispatcher, while they
+// have attempts left. Its parameters are maxAttempts, now, retryDelay in
+// seconds and the claim timeout.
+const retryCondition = `rd.attempts < ? and (
+	(rd.status = 'failed' and rd.claimed_at <= date_sub(?, interval (? << (rd.attempts - 1)) second))
+	or (rd.status = 'pending' and rd.claimed_at < ?))`
+
+// UseCase Define the interface with functions that will be used
+type UseCase interface {
+	GetAllDeliveries(itemID int64) ([]*Delivery, error)
+	Dispatch(now time.Time) (int, error)
+}
+
+// Service define the struct for service
+type Service struct {
+	DB       *sql.DB
+	notifier Notifier
+}
+
+// NewService constructor
+func NewService(db *sql.DB, n Notifier) *Service {
+	return &Service{
+		DB:       db,
+		notifier: n,
+	}
+}
+
+// GetAllDeliveries return the deliveries of the reminders of an item
+func (s *Service) GetAllDeliveries(itemID int64) ([]*Delivery, error) {
+	var result []*Delivery
+
+	stmt, err := s.DB.Prepare("select id, list_item_id, remind_at, notifier, status, error, attempts, claimed_at, delivered_at from reminder_delivery where list_item_id = ? order by claimed_at desc")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.Query(itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var d Delivery
+		err := rows.Scan(&d.ID, &d.ListItemID, &d.RemindAt, &d.Notifier, &d.Status, &d.Error, &d.Attempts, &d.ClaimedAt, &d.DeliveredAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, &d)
+	}
+
+	return result, nil
+}
+
+// Dispatch sends every reminder that is due and was not delivered yet and
+// returns how many were sent. A failing reminder does not block the others,
+// it is retried with a growing delay up to maxAttempts times.
+func (s *Service) Dispatch(now time.Time) (int, error) {
+	sql := `
+		select li.id, li.name, l.id, l.name, li.due_at, li.remind_at, rd.id
+		from list_item li
+		join list l on li.list_id = l.id
+		left join reminder_delivery rd on rd.list_item_id = li.id and rd.remind_at = li.remind_at
+		where li.remind_at <= ? and li.deleted_at is null and l.deleted_at is null
+			and (rd.id is null or (` + retryCondition + `))
+	`
+
+	stmt, err := s.DB.Prepare(sql)
+	if err != nil {
+		return 0, err
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.Query(now, maxAttempts, now, int64(retryDelay/time.Second), now.Add(-claimTimeout))
+	if err != nil {
+		return 0, err
+	}
+
+	var due []*Reminder
+	for rows.Next() {
+		var r Reminder
+		err := rows.Scan(&r.ItemID, &r.ItemName, &r.ListID, &r.ListName, &r.DueAt, &r.RemindAt, &r.deliveryID)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		due = append(due, &r)
+	}
+	rows.Close()
+
+	sent := 0
+	var firstErr error
+	for _, r := range due {
+		ok, err := s.deliver(r, now)
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("reminder of item %d: %w", r.ItemID, err)
+		}
+		if ok {
+			sent++
+		}
+	}
+
+	return sent, firstErr
+}
+
+// deliver claims the reminder, notifies it and records the outcome. The claim
+// is committed before the notifier runs so no transaction stays open during a
+// webhook call. The unique key on (list_item_id, remind_at) and the status of
+// the delivery make concurrent dispatchers skip reminders claimed by another one.
+func (s *Service) deliver(r *Reminder, now time.Time) (bool, error) {
+	id, claimed, err := s.claim(r, now)
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	err = s.notifier.Notify(r)
+	if err != nil {
+		msg := err.Error()
+		if runes := []rune(msg); len(runes) > maxErrorLength {
+			msg = string(runes[:maxErrorLength])
+		}
+
+		_, updateErr := s.DB.Exec("update reminder_delivery set status = ?, error = ? where id = ?", StatusFailed, msg, id)
+		if updateErr != nil {
+			return false, updateErr
+		}
+		return false, err
+	}
+
+	_, err = s.DB.Exec("update reminder_delivery set status = ?, error = '', delivered_at = ? where id = ?", StatusSent, now, id)
+	return err == nil, err
+}
+
+// claim records a pending delivery, or takes over a failed or abandoned one
+// counting a new attempt, and tells if this dispatcher got it
+func (s *Service) claim(r *Reminder, now time.Time) (int64, bool, error) {
+	if r.deliveryID != nil {
+		res, err := s.DB.Exec(
+			"update reminder_delivery rd set rd.status = ?, rd.notifier = ?, rd.attempts = rd.attempts + 1, rd.claimed_at = ? where rd.id = ? and "+retryCondition,
+			StatusPending, s.notifier.Name(), now, *r.deliveryID, maxAttempts, now, int64(retryDelay/time.Second), now.Add(-claimTimeout),
+		)
+		if err != nil {
+			return 0, false, err
+		}
+
+		affected, err := res.RowsAffected()
+		return *r.deliveryID, affected == 1, err
+	}
+
+	res, err := s.DB.Exec(
+		"insert into reminder_delivery (list_item_id, remind_at, notifier, status, attempts, claimed_at) values (?, ?, ?, ?, 1, ?)",
+		r.ItemID, r.RemindAt, s.notifier.Name(), StatusPending, now,
+	)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+
+	id, err := res.LastInsertId()
+	return id, err == nil, err
+}
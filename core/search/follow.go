@@ -0,0 +1,102 @@
+package search
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/cristiano-pacheco/go-api/core/event"
+	"github.com/cristiano-pacheco/go-api/core/list"
+	"github.com/cristiano-pacheco/go-api/core/logger"
+)
+
+// retryDelay is how long Follow waits before loading the index again after an error
+const retryDelay = 5 * time.Second
+
+// Source loads the lists and items to index, list.UseCase implements it
+type Source interface {
+	Get(ID int64) (*list.List, error)
+	Embed(lists []*list.List, e *list.Expand) error
+	GetChanges(cursor string) (*list.Changes, error)
+}
+
+// Follow keeps a memory index up to date with the events of the broker until
+// the broker is closed. Every event reindexes the list it belongs to, with its
+// items. The whole index is loaded again when the subscription is dropped
+// because the index fell behind, since events may have been lost.
+func Follow(idx *MemoryIndex, b *event.Broker, src Source) {
+	for !b.Closed() {
+		sub, _, _ := b.Subscribe(0, 0)
+
+		err := Load(idx, src)
+		for err == nil {
+			e, ok := <-sub.C
+			if !ok {
+				break
+			}
+			err = reindexList(idx, src, e.ListID)
+		}
+
+		if err != nil {
+			logger.Default().Error("search index failed, reloading", "error", err)
+			sub.Unsubscribe()
+			time.Sleep(retryDelay)
+		}
+	}
+}
+
+// Load fills the index with every list and item that is not in the trash
+func Load(idx *MemoryIndex, src Source) error {
+	c, err := src.GetChanges("")
+	if err != nil {
+		return err
+	}
+
+	docs := make([]*Document, 0, len(c.Lists)+len(c.Items))
+	for _, l := range c.Lists {
+		docs = append(docs, listDocument(l))
+	}
+	for _, li := range c.Items {
+		docs = append(docs, itemDocument(li))
+	}
+
+	idx.Reset(docs)
+	return nil
+}
+
+func reindexList(idx *MemoryIndex, src Source, listID int64) error {
+	l, err := src.Get(listID)
+	if errors.Is(err, sql.ErrNoRows) {
+		idx.ReplaceList(listID, nil)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	err = src.Embed([]*list.List{l}, &list.Expand{Items: true})
+	if err != nil {
+		return err
+	}
+
+	docs := []*Document{listDocument(l)}
+	var walk func(items []*list.ListItem)
+	walk = func(items []*list.ListItem) {
+		for _, li := range items {
+			docs = append(docs, itemDocument(li))
+			walk(li.Children)
+		}
+	}
+	walk(l.Items)
+
+	idx.ReplaceList(listID, docs)
+	return nil
+}
+
+func listDocument(l *list.List) *Document {
+	return &Document{Type: TypeList, ID: l.ID, ListID: l.ID, Name: l.Name, Notes: l.Notes}
+}
+
+func itemDocument(li *list.ListItem) *Document {
+	return &Document{Type: TypeListItem, ID: li.ID, ListID: li.ListID, Name: li.Name, Notes: li.Notes}
+}
@@ -0,0 +1,185 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// weight of the words of each field, a word of the name counts more than one of the notes
+const (
+	nameWeight  = 2
+	notesWeight = 1
+)
+
+// prefixFactor scales the score of a word that only starts with the query word
+const prefixFactor = 0.5
+
+type docKey struct {
+	Type string
+	ID   int64
+}
+
+// MemoryIndex is an in-process inverted index. It does not read the database,
+// documents are added with ReplaceList, usually by Follow.
+type MemoryIndex struct {
+	mu     sync.RWMutex
+	docs   map[docKey]*Document
+	terms  map[string]map[docKey]int
+	byList map[int64][]docKey
+}
+
+// NewMemoryIndex constructor
+func NewMemoryIndex() *MemoryIndex {
+	return &MemoryIndex{
+		docs:   make(map[docKey]*Document),
+		terms:  make(map[string]map[docKey]int),
+		byList: make(map[int64][]docKey),
+	}
+}
+
+// ReplaceList replaces the documents of a list, the list itself and its
+// items. Without documents the list is removed from the index.
+func (m *MemoryIndex) ReplaceList(listID int64, docs []*Document) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.removeList(listID)
+	for _, d := range docs {
+		m.add(d)
+	}
+}
+
+// Reset replaces every document of the index
+func (m *MemoryIndex) Reset(docs []*Document) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.docs = make(map[docKey]*Document)
+	m.terms = make(map[string]map[docKey]int)
+	m.byList = make(map[int64][]docKey)
+	for _, d := range docs {
+		m.add(d)
+	}
+}
+
+// Len returns the number of documents in the index
+func (m *MemoryIndex) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.docs)
+}
+
+// add must be called with the lock held
+func (m *MemoryIndex) add(d *Document) {
+	k := docKey{d.Type, d.ID}
+	m.docs[k] = d
+	m.byList[d.ListID] = append(m.byList[d.ListID], k)
+
+	for _, t := range Tokenize(d.Name) {
+		m.addTerm(t, k, nameWeight)
+	}
+	for _, t := range Tokenize(d.Notes) {
+		m.addTerm(t, k, notesWeight)
+	}
+}
+
+func (m *MemoryIndex) addTerm(term string, k docKey, weight int) {
+	postings, ok := m.terms[term]
+	if !ok {
+		postings = make(map[docKey]int)
+		m.terms[term] = postings
+	}
+	postings[k] += weight
+}
+
+// removeList must be called with the lock held
+func (m *MemoryIndex) removeList(listID int64) {
+	for _, k := range m.byList[listID] {
+		d := m.docs[k]
+		delete(m.docs, k)
+		for _, t := range append(Tokenize(d.Name), Tokenize(d.Notes)...) {
+			postings := m.terms[t]
+			delete(postings, k)
+			if len(postings) == 0 {
+				delete(m.terms, t)
+			}
+		}
+	}
+	delete(m.byList, listID)
+}
+
+// Search ranks the documents with a tf-idf score, exact word matches count
+// more than prefix matches
+func (m *MemoryIndex) Search(q string, types []string, limit int) ([]*Result, error) {
+	words := Tokenize(q)
+	if len(words) == 0 || len(types) == 0 {
+		return []*Result{}, nil
+	}
+
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var scores map[docKey]float64
+	for _, w := range words {
+		matches := make(map[docKey]float64)
+		for term, postings := range m.terms {
+			if !strings.HasPrefix(term, w) {
+				continue
+			}
+
+			factor := 1.0
+			if term != w {
+				factor = prefixFactor
+			}
+
+			idf := math.Log(1 + float64(len(m.docs))/float64(len(postings)))
+			for k, tf := range postings {
+				matches[k] += float64(tf) * idf * factor
+			}
+		}
+
+		// every word of the query must match
+		if scores == nil {
+			scores = matches
+			continue
+		}
+		for k := range scores {
+			if score, ok := matches[k]; ok {
+				scores[k] += score
+			} else {
+				delete(scores, k)
+			}
+		}
+	}
+
+	result := make([]*Result, 0, len(scores))
+	for k, score := range scores {
+		d := m.docs[k]
+		if !hasType(types, d.Type) {
+			continue
+		}
+		result = append(result, &Result{Type: d.Type, ID: d.ID, ListID: d.ListID, Name: d.Name, Notes: d.Notes, Score: score})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Score != result[j].Score {
+			return result[i].Score > result[j].Score
+		}
+		if result[i].Type != result[j].Type {
+			return result[i].Type < result[j].Type
+		}
+		return result[i].ID < result[j].ID
+	})
+
+	if len(result) > limit {
+		result = result[:limit]
+	}
+
+	return result, nil
+}
@@ -0,0 +1,82 @@
+package search_test
+
+import (
+	"testing"
+
+	"github.com/cristiano-pacheco/go-api/core/search"
+	"github.com/stretchr/testify/assert"
+)
+
+var all = []string{search.TypeList, search.TypeListItem}
+
+func newIndex() *search.MemoryIndex {
+	idx := search.NewMemoryIndex()
+	idx.ReplaceList(1, []*search.Document{
+		{Type: search.TypeList, ID: 1, ListID: 1, Name: "Mercado", Notes: "compras do mês"},
+		{Type: search.TypeListItem, ID: 1, ListID: 1, Name: "Café", Notes: "torrado"},
+		{Type: search.TypeListItem, ID: 2, ListID: 1, Name: "Pão de queijo"},
+		{Type: search.TypeListItem, ID: 3, ListID: 1, Name: "Açúcar", Notes: "para o café"},
+	})
+	idx.ReplaceList(2, []*search.Document{
+		{Type: search.TypeList, ID: 2, ListID: 2, Name: "Farmácia"},
+	})
+	return idx
+}
+
+func TestTokenize(t *testing.T) {
+	assert.Equal(t, []string{"pao", "de", "acucar", "2kg"}, search.Tokenize("Pão-de-AÇÚCAR, 2kg!"))
+}
+
+func TestMemoryIndex(t *testing.T) {
+	t.Run("TestMemoryIndex sem acentos", func(t *testing.T) {
+		results, err := newIndex().Search("acucar", all, 0)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(results))
+		assert.Equal(t, int64(3), results[0].ID)
+	})
+	t.Run("TestMemoryIndex prefixo", func(t *testing.T) {
+		results, _ := newIndex().Search("farm", all, 0)
+		assert.Equal(t, 1, len(results))
+		assert.Equal(t, search.TypeList, results[0].Type)
+		assert.Equal(t, int64(2), results[0].ID)
+	})
+	t.Run("TestMemoryIndex relevância", func(t *testing.T) {
+		results, _ := newIndex().Search("café", all, 0)
+		assert.Equal(t, 2, len(results))
+		// the name counts more than the notes
+		assert.Equal(t, int64(1), results[0].ID)
+		assert.Equal(t, int64(3), results[1].ID)
+	})
+	t.Run("TestMemoryIndex todas as palavras", func(t *testing.T) {
+		results, _ := newIndex().Search("pao queijo", all, 0)
+		assert.Equal(t, 1, len(results))
+		results, _ = newIndex().Search("pao farmacia", all, 0)
+		assert.Equal(t, 0, len(results))
+	})
+	t.Run("TestMemoryIndex remover lista", func(t *testing.T) {
+		idx := newIndex()
+		idx.ReplaceList(1, nil)
+		assert.Equal(t, 1, idx.Len())
+		results, _ := idx.Search("cafe", all, 0)
+		assert.Equal(t, 0, len(results))
+	})
+	t.Run("TestMemoryIndex tipos permitidos", func(t *testing.T) {
+		results, _ := newIndex().Search("café", []string{search.TypeList}, 0)
+		assert.Equal(t, 0, len(results))
+
+		results, _ = newIndex().Search("c", []string{search.TypeList}, 0)
+		assert.Equal(t, 1, len(results))
+		assert.Equal(t, search.TypeList, results[0].Type)
+
+		results, _ = newIndex().Search("c", []string{search.TypeListItem}, 0)
+		assert.Equal(t, 2, len(results))
+		assert.Equal(t, search.TypeListItem, results[0].Type)
+
+		results, _ = newIndex().Search("café", nil, 0)
+		assert.Equal(t, 0, len(results))
+	})
+	t.Run("TestMemoryIndex limite", func(t *testing.T) {
+		results, _ := newIndex().Search("c", all, 1)
+		assert.Equal(t, 1, len(results))
+	})
+}
@@ -0,0 +1,80 @@
+package search
+
+import (
+	"database/sql"
+	"strings"
+)
+
+// MySQLIndex searches the FULLTEXT indexes of the list and list_item tables.
+// MySQL keeps them up to date and the accent insensitive collation of the
+// columns takes care of the accents. Words shorter than innodb_ft_min_token_size
+// and stopwords are ignored by MySQL.
+type MySQLIndex struct {
+	DB *sql.DB
+}
+
+// NewMySQLIndex constructor
+func NewMySQLIndex(db *sql.DB) *MySQLIndex {
+	return &MySQLIndex{DB: db}
+}
+
+// Search ranks the documents with the relevance computed by MySQL
+func (i *MySQLIndex) Search(q string, types []string, limit int) ([]*Result, error) {
+	words := Tokenize(q)
+	if len(words) == 0 || len(types) == 0 {
+		return []*Result{}, nil
+	}
+
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+
+	// boolean mode: +word* requires a word that starts with word
+	terms := make([]string, len(words))
+	for j, w := range words {
+		terms[j] = "+" + w + "*"
+	}
+	against := strings.Join(terms, " ")
+
+	var selects []string
+	var args []interface{}
+	if hasType(types, TypeList) {
+		selects = append(selects, `
+		select ?, id, id, name, notes, match(name, notes) against (? in boolean mode) as score
+		from list
+		where deleted_at is null and match(name, notes) against (? in boolean mode)`)
+		args = append(args, TypeList, against, against)
+	}
+	if hasType(types, TypeListItem) {
+		selects = append(selects, `
+		select ?, li.id, li.list_id, li.name, li.notes, match(li.name, li.notes) against (? in boolean mode) as score
+		from list_item li join list l on l.id = li.list_id
+		where li.deleted_at is null and l.deleted_at is null and match(li.name, li.notes) against (? in boolean mode)`)
+		args = append(args, TypeListItem, against, against)
+	}
+	if len(selects) == 0 {
+		return []*Result{}, nil
+	}
+	args = append(args, limit)
+
+	rows, err := i.DB.Query(strings.Join(selects, "\n\t\tunion all")+`
+		order by score desc, 1, 2
+		limit ?`, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	result := []*Result{}
+	for rows.Next() {
+		var r Result
+		err := rows.Scan(&r.Type, &r.ID, &r.ListID, &r.Name, &r.Notes, &r.Score)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, &r)
+	}
+
+	return result, rows.Err()
+}
@@ -0,0 +1,82 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// document types
+const (
+	TypeList     = "list"
+	TypeListItem = "list_item"
+)
+
+// DefaultLimit is the number of results returned when no limit is given
+const DefaultLimit = 20
+
+// Document is a list or an item as it is indexed
+type Document struct {
+	Type   string
+	ID     int64
+	ListID int64
+	Name   string
+	Notes  string
+}
+
+// Result is a list or an item that matches a search, the best matches have
+// the highest score
+type Result struct {
+	Type   string  `json:"type"`
+	ID     int64   `json:"id"`
+	ListID int64   `json:"list_id"`
+	Name   string  `json:"name"`
+	Notes  string  `json:"notes"`
+	Score  float64 `json:"score"`
+}
+
+// Index finds the lists and items that are not in the trash by the words of
+// their name and notes. Every word of the query must match the start of a word
+// of the document, regardless of case and accents. Only the documents of the
+// given types are returned, the ones the caller is allowed to read.
+type Index interface {
+	Search(q string, types []string, limit int) ([]*Result, error)
+}
+
+func hasType(types []string, typ string) bool {
+	for _, t := range types {
+		if t == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// accents maps the accented letters used in Portuguese and other latin
+// languages to the letter without the accent
+var accents = map[rune]rune{
+	'á': 'a', 'à': 'a', 'â': 'a', 'ã': 'a', 'ä': 'a', 'å': 'a',
+	'é': 'e', 'è': 'e', 'ê': 'e', 'ë': 'e',
+	'í': 'i', 'ì': 'i', 'î': 'i', 'ï': 'i',
+	'ó': 'o', 'ò': 'o', 'ô': 'o', 'õ': 'o', 'ö': 'o',
+	'ú': 'u', 'ù': 'u', 'û': 'u', 'ü': 'u',
+	'ç': 'c', 'ñ': 'n', 'ý': 'y', 'ÿ': 'y',
+}
+
+// Fold lowercases a text and removes its accents
+func Fold(s string) string {
+	return strings.Map(func(r rune) rune {
+		r = unicode.ToLower(r)
+		if folded, ok := accents[r]; ok {
+			return folded
+		}
+		return r
+	}, s)
+}
+
+// Tokenize splits a text in folded words, anything that is not a letter or a
+// digit separates words
+func Tokenize(s string) []string {
+	return strings.FieldsFunc(Fold(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
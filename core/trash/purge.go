@@ -0,0 +1,30 @@
+package trash
+
+import (
+	"time"
+
+	"github.com/cristiano-pacheco/go-api/core/worker"
+)
+
+// Purger is implemented by the services that keep removed records in a trash
+type Purger interface {
+	Purge(before time.Time) (int64, error)
+}
+
+// PurgeJob returns a worker job that deletes for good the records kept in the
+// trash for longer than retention. Every purger runs even if a previous one
+// failed, the first error is returned.
+func PurgeJob(retention time.Duration, purgers ...Purger) worker.Job {
+	return func(now time.Time) error {
+		var first error
+
+		for _, p := range purgers {
+			_, err := p.Purge(now.Add(-retention))
+			if err != nil && first == nil {
+				first = err
+			}
+		}
+
+		return first
+	}
+}
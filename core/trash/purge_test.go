@@ -0,0 +1,40 @@
+package trash_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cristiano-pacheco/go-api/core/trash"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakePurger struct {
+	before time.Time
+	err    error
+}
+
+func (f *fakePurger) Purge(before time.Time) (int64, error) {
+	f.before = before
+	return 0, f.err
+}
+
+func TestPurgeJob(t *testing.T) {
+	now := time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("caminho feliz", func(t *testing.T) {
+		users, lists := &fakePurger{}, &fakePurger{}
+		err := trash.PurgeJob(48*time.Hour, users, lists)(now)
+		assert.Nil(t, err)
+		assert.Equal(t, now.Add(-48*time.Hour), users.before)
+		assert.Equal(t, now.Add(-48*time.Hour), lists.before)
+	})
+
+	t.Run("erro nao interrompe os demais", func(t *testing.T) {
+		failure := errors.New("database is down")
+		users, lists := &fakePurger{err: failure}, &fakePurger{}
+		err := trash.PurgeJob(time.Hour, users, lists)(now)
+		assert.Equal(t, failure, err)
+		assert.Equal(t, now.Add(-time.Hour), lists.before)
+	})
+}
@@ -2,7 +2,10 @@ package user
 
 import (
 	"database/sql"
-	"fmt"
+	"time"
+
+	"github.com/cristiano-pacheco/go-api/core/apperror"
+	"github.com/cristiano-pacheco/go-api/core/query"
 
 	_ "github.com/go-sql-driver/mysql" // OK
 	"golang.org/x/crypto/bcrypt"
@@ -10,12 +13,15 @@ import (
 
 // UseCase Define the interface with functions that will be used
 type UseCase interface {
-	GetAll() ([]*User, error)
+	GetAll(p *query.Params) ([]*User, *query.Result, error)
 	Get(ID int64) (*User, error)
 	Store(u *User) error
 	Update(u *User) error
 	UpdatePassword(u *User) error
 	Remove(ID int64) error
+	GetTrash() ([]*User, error)
+	RestoreFromTrash(ID int64) error
+	Purge(before time.Time) (int64, error)
 }
 
 // Service define the struct for user service
@@ -32,37 +38,91 @@ func NewService(db *sql.DB, v *Validator) *Service {
 	}
 }
 
-// GetAll return all users from database
-func (s *Service) GetAll() ([]*User, error) {
-	var result []*User
+// QuerySpec lists the fields users can be sorted and filtered on
+var QuerySpec = &query.Spec{
+	Fields: map[string]*query.Field{
+		"id": {
+			Column: "id", Kind: query.Int, Sortable: true,
+			Value: func(row interface{}) interface{} { return row.(*User).ID },
+		},
+		"name": {
+			Column: "name", Kind: query.String, Sortable: true,
+			Value: func(row interface{}) interface{} { return row.(*User).Name },
+		},
+		"email": {
+			Column: "email", Kind: query.String, Sortable: true,
+			Value: func(row interface{}) interface{} { return row.(*User).Email },
+		},
+		"created_at": {
+			Column: "created_at", Kind: query.Time, Sortable: true,
+			Value: func(row interface{}) interface{} { return row.(*User).CreatedAt },
+		},
+		"updated_at": {
+			Column: "updated_at", Kind: query.Time, Sortable: true,
+			Value: func(row interface{}) interface{} { return row.(*User).UpdatedAt },
+		},
+		"is_active": {Column: "is_active", Kind: query.Bool, Filterable: true},
+		"is_admin":  {Column: "is_admin", Kind: query.Bool, Filterable: true},
+	},
+	DefaultSort:  "id",
+	IDColumn:     "id",
+	ID:           func(row interface{}) int64 { return row.(*User).ID },
+	SearchColumn: "name",
+}
+
+// GetAll return a page of users from database, a nil p returns the first page
+func (s *Service) GetAll(p *query.Params) ([]*User, *query.Result, error) {
+	if p == nil {
+		p = query.New(QuerySpec)
+	}
 
-	rows, err := s.DB.Query("select id, name, email, is_active, is_admin, created_at, updated_at from user")
+	result := []*User{}
+
+	conds, args := p.PageConditions()
+	conds = append([]string{"deleted_at is null"}, conds...)
+	rows, err := s.DB.Query(
+		"select id, name, email, locale, is_active, is_admin, version, created_at, updated_at from user"+query.Where(conds)+p.OrderBy()+p.LimitClause(),
+		args...,
+	)
 
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	defer rows.Close()
 
 	for rows.Next() {
 		var u User
-		err := rows.Scan(&u.ID, &u.Name, &u.Email, &u.IsActive, &u.IsAdmin, &u.CreatedAt, &u.UpdatedAt)
+		err := rows.Scan(&u.ID, &u.Name, &u.Email, &u.Locale, &u.IsActive, &u.IsAdmin, &u.Version, &u.CreatedAt, &u.UpdatedAt)
 
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
 		result = append(result, &u)
 	}
 
-	return result, nil
+	res := &query.Result{}
+	if p.HasMore(len(result)) {
+		result = result[:p.Limit]
+		res.NextCursor = p.NextCursor(result[len(result)-1])
+	}
+
+	conds, args = p.Conditions()
+	conds = append([]string{"deleted_at is null"}, conds...)
+	err = s.DB.QueryRow("select count(1) from user"+query.Where(conds), args...).Scan(&res.Total)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return result, res, nil
 }
 
 // Get the user from database
 func (s *Service) Get(ID int64) (*User, error) {
 	var u User
 
-	stmt, err := s.DB.Prepare("select id, name, email, password, is_active, is_admin, created_at, updated_at from user where id = ?")
+	stmt, err := s.DB.Prepare("select id, name, email, password, locale, is_active, is_admin, version, created_at, updated_at from user where id = ? and deleted_at is null")
 
 	if err != nil {
 		return nil, err
@@ -70,10 +130,10 @@ func (s *Service) Get(ID int64) (*User, error) {
 
 	defer stmt.Close()
 
-	err = stmt.QueryRow(ID).Scan(&u.ID, &u.Name, &u.Email, &u.Password, &u.IsActive, &u.IsAdmin, &u.CreatedAt, &u.UpdatedAt)
+	err = stmt.QueryRow(ID).Scan(&u.ID, &u.Name, &u.Email, &u.Password, &u.Locale, &u.IsActive, &u.IsAdmin, &u.Version, &u.CreatedAt, &u.UpdatedAt)
 
 	if err != nil {
-		return nil, err
+		return nil, apperror.FromDB(err, "user")
 	}
 
 	return &u, nil
@@ -92,23 +152,24 @@ func (s *Service) Store(u *User) error {
 	}
 
 	u.Password = string(hashedPassword)
+	u.Version = 1
 
 	tx, err := s.DB.Begin()
 	if err != nil {
 		return err
 	}
 
-	stmt, err := tx.Prepare("insert into user(id, name, email, password, is_active, is_admin) values (?, ?, ?, ?, ?, ?)")
+	stmt, err := tx.Prepare("insert into user(id, name, email, password, locale, is_active, is_admin) values (?, ?, ?, ?, ?, ?, ?)")
 	if err != nil {
 		return err
 	}
 
 	defer stmt.Close()
 
-	_, err = stmt.Exec(u.ID, u.Name, u.Email, u.Password, u.IsActive, u.IsAdmin)
+	_, err = stmt.Exec(u.ID, u.Name, u.Email, u.Password, u.Locale, u.IsActive, u.IsAdmin)
 	if err != nil {
 		tx.Rollback()
-		return err
+		return apperror.FromDB(err, "user")
 	}
 
 	tx.Commit()
@@ -116,7 +177,9 @@ func (s *Service) Store(u *User) error {
 	return nil
 }
 
-// Update an user in the database
+// Update an user in the database. The update only happens if u.Version is the
+// current version of the user, otherwise an *apperror.VersionConflictError is
+// returned. A zero version skips the check. On success u.Version is the new version.
 func (s *Service) Update(u *User) error {
 	err := s.validator.validateUserUpdateData(u)
 	if err != nil {
@@ -128,19 +191,43 @@ func (s *Service) Update(u *User) error {
 		return err
 	}
 
-	stmt, err := tx.Prepare("update user set name =?, email = ?, is_active = ?, is_admin = ? where id = ?")
+	query := "update user set name =?, email = ?, locale = ?, is_active = ?, is_admin = ?, version = version + 1 where id = ? and deleted_at is null"
+	args := []interface{}{u.Name, u.Email, u.Locale, u.IsActive, u.IsAdmin, u.ID}
+	if u.Version != 0 {
+		query += " and version = ?"
+		args = append(args, u.Version)
+	}
+
+	res, err := tx.Exec(query, args...)
+	if err != nil {
+		tx.Rollback()
+		return apperror.FromDB(err, "user")
+	}
+
+	affected, err := res.RowsAffected()
 	if err != nil {
 		tx.Rollback()
 		return err
 	}
 
-	_, err = stmt.Exec(u.Name, u.Email, u.IsActive, u.IsAdmin, u.ID)
+	var current int64
+	err = tx.QueryRow("select version from user where id = ? and deleted_at is null", u.ID).Scan(&current)
 	if err != nil {
 		tx.Rollback()
+		return apperror.FromDB(err, "user")
+	}
+
+	if affected == 0 {
+		tx.Rollback()
+		return &apperror.VersionConflictError{Resource: "user", ID: u.ID, Expected: u.Version, Current: current}
+	}
+
+	err = tx.Commit()
+	if err != nil {
 		return err
 	}
 
-	tx.Commit()
+	u.Version = current
 
 	return nil
 }
@@ -164,7 +251,7 @@ func (s *Service) UpdatePassword(u *User) error {
 		return err
 	}
 
-	stmt, err := tx.Prepare("update user set password =? where id = ?")
+	stmt, err := tx.Prepare("update user set password =? where id = ? and deleted_at is null")
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -181,10 +268,10 @@ func (s *Service) UpdatePassword(u *User) error {
 	return nil
 }
 
-// Remove an user from the database
+// Remove moves an user to the trash, it is deleted for good by Purge
 func (s *Service) Remove(ID int64) error {
 	if ID == 0 {
-		return fmt.Errorf("invalid ID")
+		return apperror.Validation("invalid ID")
 	}
 
 	tx, err := s.DB.Begin()
@@ -192,13 +279,78 @@ func (s *Service) Remove(ID int64) error {
 		return err
 	}
 
-	_, err = tx.Exec("delete from user where id = ?", ID)
+	res, err := tx.Exec("update user set deleted_at = now() where id = ? and deleted_at is null", ID)
 	if err != nil {
 		tx.Rollback()
 		return err
 	}
 
-	tx.Commit()
+	affected, err := res.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if affected == 0 {
+		tx.Rollback()
+		return apperror.NotFound("user %d not found", ID)
+	}
+
+	return tx.Commit()
+}
+
+// GetTrash return the users in the trash, the most recently removed first
+func (s *Service) GetTrash() ([]*User, error) {
+	var result []*User
+
+	rows, err := s.DB.Query("select id, name, email, locale, is_active, is_admin, version, created_at, updated_at, deleted_at from user where deleted_at is not null order by deleted_at desc")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var u User
+		err := rows.Scan(&u.ID, &u.Name, &u.Email, &u.Locale, &u.IsActive, &u.IsAdmin, &u.Version, &u.CreatedAt, &u.UpdatedAt, &u.DeletedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, &u)
+	}
+
+	return result, nil
+}
+
+// RestoreFromTrash brings back an user removed by Remove. The email of an user
+// in the trash is free, restoring fails with a conflict if another user took it.
+func (s *Service) RestoreFromTrash(ID int64) error {
+	res, err := s.DB.Exec("update user set deleted_at = null, version = version + 1 where id = ? and deleted_at is not null", ID)
+	if err != nil {
+		return apperror.FromDB(err, "user")
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return apperror.NotFound("user %d is not in the trash", ID)
+	}
 
 	return nil
 }
+
+// Purge deletes for good the users removed before the given time
+func (s *Service) Purge(before time.Time) (int64, error) {
+	res, err := s.DB.Exec("delete from user where deleted_at < ?", before)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/cristiano-pacheco/go-api/core/apperror"
 	"github.com/cristiano-pacheco/go-api/core/user"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/stretchr/testify/assert"
@@ -77,7 +78,7 @@ func TestGetAll(t *testing.T) {
 	b2 := newData(2)
 	_ = service.Store(b1)
 	_ = service.Store(b2)
-	saved, err := service.GetAll()
+	saved, _, err := service.GetAll(nil)
 	assert.Nil(t, err)
 	assert.Equal(t, 2, len(saved))
 }
@@ -113,3 +114,23 @@ func TestUpdate(t *testing.T) {
 		}
 	})
 }
+
+func TestEmailReuseAfterTrash(t *testing.T) {
+	db := getDB(t)
+	defer clearAndClose(db, t)
+	service := user.NewService(db, &user.Validator{})
+	assert.Nil(t, service.Store(newData(1)))
+	assert.Nil(t, service.Remove(1))
+
+	again := newData(2)
+	again.Email = "email1@gmail.com"
+	assert.Nil(t, service.Store(again), "the email of a user in the trash is free")
+
+	err := service.RestoreFromTrash(1)
+	assert.Equal(t, apperror.ConflictKind, apperror.KindOf(err))
+
+	err = service.Remove(1)
+	assert.Equal(t, apperror.NotFoundKind, apperror.KindOf(err), "a user in the trash is not removed again")
+	err = service.Remove(99)
+	assert.Equal(t, apperror.NotFoundKind, apperror.KindOf(err))
+}
@@ -1,8 +1,7 @@
 package user
 
 import (
-	"fmt"
-
+	"github.com/cristiano-pacheco/go-api/core/apperror"
 	"github.com/cristiano-pacheco/go-api/core/validator"
 )
 
@@ -10,61 +9,21 @@ import (
 type Validator struct{}
 
 func (uv *Validator) validateUserCreationData(u *User) error {
-	err := validator.NotEmpty("name", u.Name)
-	if err != nil {
-		return err
-	}
-
-	err = validator.NotEmpty("email", u.Email)
-	if err != nil {
-		return err
-	}
-
-	err = validator.Email("email", u.Email)
-	if err != nil {
-		return err
-	}
-
-	err = validator.NotEmpty("password", u.Password)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return validator.Validate(u, validator.Create)
 }
 
 func (uv *Validator) validateUserUpdateData(u *User) error {
 	if u.ID == 0 {
-		return fmt.Errorf("invalid ID")
+		return apperror.New(apperror.ValidationKind, "invalid_id", "invalid ID")
 	}
 
-	err := validator.NotEmpty("name", u.Name)
-	if err != nil {
-		return err
-	}
-
-	err = validator.NotEmpty("email", u.Email)
-	if err != nil {
-		return err
-	}
-
-	err = validator.Email("email", u.Email)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return validator.Validate(u, validator.Update)
 }
 
 func (uv *Validator) validateUserUpdatePasswordData(u *User) error {
 	if u.ID == 0 {
-		return fmt.Errorf("invalid ID")
-	}
-
-	err := validator.NotEmpty("password", u.Name)
-	if err != nil {
-		return err
+		return apperror.New(apperror.ValidationKind, "invalid_id", "invalid ID")
 	}
 
-	return nil
+	return validator.ValidatePartial(u, validator.Password)
 }
@@ -0,0 +1,408 @@
+package validator
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cristiano-pacheco/go-api/core/apperror"
+)
+
+// operations with their own rule sets, the rules of the validate tag apply to
+// every operation and the ones of validate_<operation> only to that one, e.g.
+//
+//	Password string `validate:"max=72" validate_create:"required"`
+//
+// Password changes a single field, it is checked with ValidatePartial.
+const (
+	Create   = "create"
+	Update   = "update"
+	Password = "password"
+)
+
+// Context is the field a rule checks. Pointers are dereferenced before the
+// rules run and nil pointers are only checked by required.
+type Context struct {
+	// Field is the name of the field in the API, from its json tag
+	Field string
+	Value reflect.Value
+	// Param is the text after the = of the rule, e.g. 255 in max=255
+	Param string
+
+	parent reflect.Value
+	info   *typeInfo
+}
+
+// Sibling returns the value and the API name of another field of the struct
+// by its Go name, for cross-field rules. ok is false when the field does not
+// exist or is a nil pointer.
+func (c *Context) Sibling(name string) (value reflect.Value, field string, ok bool) {
+	f, found := c.info.byName[name]
+	if !found {
+		return reflect.Value{}, "", false
+	}
+
+	v := c.parent.Field(f.index)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return reflect.Value{}, f.name, false
+		}
+		v = v.Elem()
+	}
+
+	return v, f.name, true
+}
+
+// Rule checks a field, it returns an error made by apperror.InvalidField when
+// the value is invalid
+type Rule func(c *Context) error
+
+// Engine validates structs by the rules in their validate tags. The rules of
+// a type are parsed once and cached.
+type Engine struct {
+	mu    sync.RWMutex
+	rules map[string]Rule
+	types sync.Map
+}
+
+type typeInfo struct {
+	fields []*fieldInfo
+	byName map[string]*fieldInfo
+}
+
+type fieldInfo struct {
+	index int
+	name  string
+	// rules by operation, "" holds the rules of every operation
+	rules map[string][]ruleCall
+}
+
+type ruleCall struct {
+	name  string
+	param string
+}
+
+// NewEngine returns an engine with the built-in rules: required, omitempty,
+// email, min, max, oneof, ltefield, gtefield and nefield. The options of
+// oneof are separated by spaces, e.g. oneof=en pt-BR.
+func NewEngine() *Engine {
+	e := &Engine{rules: make(map[string]Rule)}
+	e.RegisterRule("required", required)
+	e.RegisterRule("email", emailRule)
+	e.RegisterRule("min", minRule)
+	e.RegisterRule("max", maxRule)
+	e.RegisterRule("oneof", oneOf)
+	e.RegisterRule("ltefield", lteField)
+	e.RegisterRule("gtefield", gteField)
+	e.RegisterRule("nefield", neField)
+	return e
+}
+
+// RegisterRule adds a rule or replaces the one with the same name
+func (e *Engine) RegisterRule(name string, rule Rule) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.rules[name] = rule
+}
+
+// Validate checks the struct v points to with the rules of an operation and
+// returns a validation error with every invalid field
+func (e *Engine) Validate(v interface{}, op string) error {
+	return e.validate(v, op, false)
+}
+
+// ValidatePartial is Validate for operations that change part of a struct,
+// only the fields with a validate_<operation> tag are checked
+func (e *Engine) ValidatePartial(v interface{}, op string) error {
+	return e.validate(v, op, true)
+}
+
+func (e *Engine) validate(v interface{}, op string, partial bool) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("validator: cannot validate %T, it is not a struct", v))
+	}
+
+	info := e.describe(rv.Type())
+
+	var errs Errors
+	for _, f := range info.fields {
+		if partial && len(f.rules[op]) == 0 {
+			continue
+		}
+
+		calls := make([]ruleCall, 0, len(f.rules[""])+len(f.rules[op]))
+		calls = append(append(calls, f.rules[""]...), f.rules[op]...)
+		if len(calls) == 0 {
+			continue
+		}
+
+		c := &Context{Field: f.name, Value: rv.Field(f.index), parent: rv, info: info}
+		errs.Add(e.check(c, calls))
+	}
+
+	return errs.Err()
+}
+
+// check runs the rules of a field until one fails
+func (e *Engine) check(c *Context, calls []ruleCall) error {
+	isNil := c.Value.Kind() == reflect.Ptr && c.Value.IsNil()
+	if c.Value.Kind() == reflect.Ptr && !isNil {
+		c.Value = c.Value.Elem()
+	}
+
+	for _, call := range calls {
+		if call.name == "omitempty" {
+			if isNil || c.Value.IsZero() {
+				return nil
+			}
+			continue
+		}
+
+		if isNil && call.name != "required" {
+			continue
+		}
+
+		e.mu.RLock()
+		rule, ok := e.rules[call.name]
+		e.mu.RUnlock()
+		if !ok {
+			panic(fmt.Sprintf("validator: unknown rule %q on %s", call.name, c.Field))
+		}
+
+		c.Param = call.param
+		err := rule(c)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e *Engine) describe(t reflect.Type) *typeInfo {
+	if info, ok := e.types.Load(t); ok {
+		return info.(*typeInfo)
+	}
+
+	info := &typeInfo{byName: make(map[string]*fieldInfo)}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+
+		f := &fieldInfo{index: i, name: apiName(sf), rules: make(map[string][]ruleCall)}
+		for _, op := range []string{"", Create, Update, Password} {
+			key := "validate"
+			if op != "" {
+				key += "_" + op
+			}
+			if tag := sf.Tag.Get(key); tag != "" {
+				f.rules[op] = parseRules(tag)
+			}
+		}
+
+		info.fields = append(info.fields, f)
+		info.byName[sf.Name] = f
+	}
+
+	actual, _ := e.types.LoadOrStore(t, info)
+	return actual.(*typeInfo)
+}
+
+func apiName(sf reflect.StructField) string {
+	name := strings.SplitN(sf.Tag.Get("json"), ",", 2)[0]
+	if name == "" || name == "-" {
+		return strings.ToLower(sf.Name)
+	}
+
+	return name
+}
+
+func parseRules(tag string) []ruleCall {
+	var calls []ruleCall
+	for _, r := range strings.Split(tag, ",") {
+		parts := strings.SplitN(strings.TrimSpace(r), "=", 2)
+		call := ruleCall{name: parts[0]}
+		if len(parts) == 2 {
+			call.param = parts[1]
+		}
+		calls = append(calls, call)
+	}
+
+	return calls
+}
+
+var std = NewEngine()
+
+// Validate checks a struct with the default engine
+func Validate(v interface{}, op string) error {
+	return std.Validate(v, op)
+}
+
+// ValidatePartial checks the fields of an operation with the default engine
+func ValidatePartial(v interface{}, op string) error {
+	return std.ValidatePartial(v, op)
+}
+
+// RegisterRule adds a rule to the default engine, it should be called from
+// an init function so the rule exists before anything is validated
+func RegisterRule(name string, rule Rule) {
+	std.RegisterRule(name, rule)
+}
+
+func required(c *Context) error {
+	if c.Value.Kind() == reflect.String {
+		return NotEmpty(c.Field, c.Value.String())
+	}
+
+	if !c.Value.IsValid() || c.Value.IsZero() {
+		return apperror.InvalidField(c.Field, CodeRequired, "%s cannot be empty")
+	}
+
+	return nil
+}
+
+func emailRule(c *Context) error {
+	if c.Value.String() == "" {
+		return nil
+	}
+
+	return Email(c.Field, c.Value.String())
+}
+
+func minRule(c *Context) error {
+	if c.Value.Kind() == reflect.String {
+		return MinLength(c.Field, c.Value.String(), intParam(c))
+	}
+
+	if number(c.Value) < floatParam(c) {
+		return apperror.InvalidField(c.Field, CodeMin, "%s cannot be less than %s", c.Param)
+	}
+
+	return nil
+}
+
+func maxRule(c *Context) error {
+	if c.Value.Kind() == reflect.String {
+		return MaxLength(c.Field, c.Value.String(), intParam(c))
+	}
+
+	if number(c.Value) > floatParam(c) {
+		return apperror.InvalidField(c.Field, CodeMax, "%s cannot be greater than %s", c.Param)
+	}
+
+	return nil
+}
+
+func lteField(c *Context) error {
+	other, name, ok := c.Sibling(c.Param)
+	if !ok || compare(c.Value, other) <= 0 {
+		return nil
+	}
+
+	if _, isTime := c.Value.Interface().(time.Time); isTime {
+		return fieldError(c, "after_field", "%s cannot be after %s", name)
+	}
+
+	return fieldError(c, "greater_than_field", "%s cannot be greater than %s", name)
+}
+
+func gteField(c *Context) error {
+	other, name, ok := c.Sibling(c.Param)
+	if !ok || compare(c.Value, other) >= 0 {
+		return nil
+	}
+
+	if _, isTime := c.Value.Interface().(time.Time); isTime {
+		return fieldError(c, "before_field", "%s cannot be before %s", name)
+	}
+
+	return fieldError(c, "less_than_field", "%s cannot be less than %s", name)
+}
+
+func neField(c *Context) error {
+	other, name, ok := c.Sibling(c.Param)
+	if !ok || compare(c.Value, other) != 0 {
+		return nil
+	}
+
+	return fieldError(c, "equal_field", "%s cannot be equal to %s", name)
+}
+
+func oneOf(c *Context) error {
+	options := strings.Fields(c.Param)
+	for _, o := range options {
+		if c.Value.String() == o {
+			return nil
+		}
+	}
+
+	return apperror.InvalidField(c.Field, CodeOneOf, "%s must be one of %s", strings.Join(options, ", "))
+}
+
+// fieldError returns the error of a cross-field rule, its message has its own key
+func fieldError(c *Context, key string, format string, params ...interface{}) error {
+	return apperror.InvalidFields([]*apperror.FieldError{apperror.NewFieldError(c.Field, CodeInvalid, key, format, params...)})
+}
+
+// compare compares two times or two numbers
+func compare(a reflect.Value, b reflect.Value) int {
+	if at, ok := a.Interface().(time.Time); ok {
+		bt := b.Interface().(time.Time)
+		switch {
+		case at.Before(bt):
+			return -1
+		case at.After(bt):
+			return 1
+		}
+		return 0
+	}
+
+	an, bn := number(a), number(b)
+	switch {
+	case an < bn:
+		return -1
+	case an > bn:
+		return 1
+	}
+	return 0
+}
+
+func number(v reflect.Value) float64 {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	}
+
+	panic(fmt.Sprintf("validator: %s is not a number", v.Type()))
+}
+
+func intParam(c *Context) int {
+	n, err := strconv.Atoi(c.Param)
+	if err != nil {
+		panic(fmt.Sprintf("validator: invalid parameter %q on %s", c.Param, c.Field))
+	}
+
+	return n
+}
+
+func floatParam(c *Context) float64 {
+	n, err := strconv.ParseFloat(c.Param, 64)
+	if err != nil {
+		panic(fmt.Sprintf("validator: invalid parameter %q on %s", c.Param, c.Field))
+	}
+
+	return n
+}
@@ -0,0 +1,71 @@
+package validator
+
+import (
+	"errors"
+
+	"github.com/cristiano-pacheco/go-api/core/apperror"
+)
+
+// error codes of the field errors, they are part of the API and must not change
+const (
+	CodeRequired  = "required"
+	CodeEmail     = "email"
+	CodeMaxLength = "max_length"
+	CodeMinLength = "min_length"
+	CodeMin       = "min"
+	CodeMax       = "max"
+	CodeOneOf     = "oneof"
+	CodeInvalid   = "invalid"
+)
+
+// Errors collects every field error of a validation instead of stopping at
+// the first one. It marshals to a list of {field, code, message}.
+type Errors []*apperror.FieldError
+
+// Add records the field errors of a check, nil is ignored so checks can be
+// passed directly. Errors that are not about a field are recorded with an
+// empty field and the invalid code.
+func (e *Errors) Add(err error) {
+	if err == nil {
+		return
+	}
+
+	fields := apperror.FieldsOf(err)
+	if len(fields) == 0 {
+		f := &apperror.FieldError{Code: CodeInvalid, Message: err.Error()}
+		var ae *apperror.Error
+		if errors.As(err, &ae) {
+			f.Key, f.Params = ae.Key, ae.Args
+		}
+		fields = []*apperror.FieldError{f}
+	}
+
+	*e = append(*e, fields...)
+}
+
+// Field records an error of a field, the code is the key of the message and
+// the format receives the field followed by the params
+func (e *Errors) Field(field string, code string, format string, params ...interface{}) {
+	*e = append(*e, apperror.NewFieldError(field, code, code, format, params...))
+}
+
+// Has tells if an error was recorded for a field
+func (e Errors) Has(field string) bool {
+	for _, f := range e {
+		if f.Field == field {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Err returns nil when nothing was recorded, otherwise a validation error
+// with every field error
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+
+	return apperror.InvalidFields(e)
+}
@@ -1,10 +1,11 @@
 package validator
 
 import (
-	"fmt"
 	"regexp"
 	"strings"
 	"unicode/utf8"
+
+	"github.com/cristiano-pacheco/go-api/core/apperror"
 )
 
 // NotEmpty Implement a Required method to check that specific fields in the form
@@ -12,7 +13,7 @@ import (
 // appropriate message to the form errors.
 func NotEmpty(field string, value string) error {
 	if strings.TrimSpace(value) == "" {
-		return fmt.Errorf("%s cannot be empty", field)
+		return apperror.InvalidField(field, CodeRequired, "%s cannot be empty")
 	}
 	return nil
 }
@@ -22,7 +23,7 @@ func NotEmpty(field string, value string) error {
 // appropriate message to the form errors.
 func MaxLength(field string, value string, d int) error {
 	if utf8.RuneCountInString(value) > d {
-		return fmt.Errorf("%s is too long (maximum is %d characters)", field, d)
+		return apperror.InvalidField(field, CodeMaxLength, "%s is too long (maximum is %d characters)", d)
 	}
 	return nil
 }
@@ -32,7 +33,7 @@ func MaxLength(field string, value string, d int) error {
 // appropriate message to the form errors.
 func MinLength(field string, value string, d int) error {
 	if utf8.RuneCountInString(value) < d {
-		return fmt.Errorf("%s is too short (minimum is %d characters)", field, d)
+		return apperror.InvalidField(field, CodeMinLength, "%s is too short (minimum is %d characters)", d)
 	}
 	return nil
 }
@@ -43,7 +44,7 @@ var emailRegex = regexp.MustCompile("^[a-zA-Z0-9.!#$%&'*+\\/=?^_`{|}~-]+@[a-zA-Z
 // Email validate email
 func Email(field string, value string) error {
 	if IsEmail(value) == false {
-		return fmt.Errorf("%s is not a valid email", field)
+		return apperror.InvalidField(field, CodeEmail, "%s is not a valid email")
 	}
 	return nil
 }
@@ -62,7 +63,7 @@ func IsEmail(value string) bool {
 // appropriate message to the form errors.
 func MatchesPattern(value string, pattern *regexp.Regexp) error {
 	if !pattern.MatchString(value) {
-		return fmt.Errorf("is invalid")
+		return apperror.Validation("is invalid")
 	}
 	return nil
 }
@@ -1,8 +1,11 @@
 package validator_test
 
 import (
+	"encoding/json"
 	"testing"
+	"time"
 
+	"github.com/cristiano-pacheco/go-api/core/apperror"
 	"github.com/cristiano-pacheco/go-api/core/validator"
 	"github.com/stretchr/testify/assert"
 )
@@ -35,3 +38,109 @@ func TestIsEmail(t *testing.T) {
 	assert.Equal(t, false, validator.IsEmail("teste"))
 	assert.Equal(t, false, validator.IsEmail("teste@"))
 }
+
+func TestErrors(t *testing.T) {
+	var errs validator.Errors
+	assert.Nil(t, errs.Err())
+
+	errs.Add(validator.NotEmpty("name", ""))
+	errs.Add(validator.Email("email", "test@gmail.com"))
+	errs.Add(validator.MaxLength("tag", "bbccc", 3))
+	errs.Field("price", validator.CodeInvalid, "%s cannot be negative")
+	errs.Add(apperror.New(apperror.ValidationKind, "invalid_id", "invalid ID"))
+
+	assert.True(t, errs.Has("name"))
+	assert.False(t, errs.Has("email"))
+
+	err := errs.Err()
+	assert.Equal(t, apperror.ValidationKind, apperror.KindOf(err))
+	assert.Equal(t, "name cannot be empty; tag is too long (maximum is 3 characters); price cannot be negative; invalid ID", err.Error())
+
+	fields := apperror.FieldsOf(err)
+	assert.Len(t, fields, 4)
+	assert.Equal(t, apperror.NewFieldError("name", validator.CodeRequired, validator.CodeRequired, "%s cannot be empty"), fields[0])
+	assert.Equal(t, validator.CodeMaxLength, fields[1].Code)
+	assert.Equal(t, "price", fields[2].Field)
+	assert.Equal(t, &apperror.FieldError{Code: validator.CodeInvalid, Message: "invalid ID", Key: "invalid_id"}, fields[3])
+
+	raw, _ := json.Marshal(errs)
+	assert.JSONEq(t, `[
+		{"field": "name", "code": "required", "message": "name cannot be empty"},
+		{"field": "tag", "code": "max_length", "message": "tag is too long (maximum is 3 characters)"},
+		{"field": "price", "code": "invalid", "message": "price cannot be negative"},
+		{"field": "", "code": "invalid", "message": "invalid ID"}
+	]`, string(raw))
+}
+
+type account struct {
+	ID       int64      `json:"id"`
+	Name     string     `json:"name" validate:"required,max=5"`
+	Email    string     `json:"email" validate:"email"`
+	Password string     `json:"-" validate_create:"required,min=3" validate_password:"required,min=3"`
+	Code     string     `json:"code" validate:"omitempty,even"`
+	Age      *int       `json:"age" validate:"min=18"`
+	StartsAt *time.Time `json:"starts_at"`
+	EndsAt   *time.Time `json:"ends_at" validate:"gtefield=StartsAt"`
+}
+
+func TestEngine(t *testing.T) {
+	e := validator.NewEngine()
+	e.RegisterRule("even", func(c *validator.Context) error {
+		if len(c.Value.String())%2 != 0 {
+			return apperror.InvalidField(c.Field, validator.CodeInvalid, "%s must have an even length")
+		}
+		return nil
+	})
+
+	t.Run("Teste dados válidos", func(t *testing.T) {
+		age := 20
+		a := &account{Name: "Ana", Email: "ana@gmail.com", Password: "secret", Age: &age}
+		assert.Nil(t, e.Validate(a, validator.Create))
+	})
+
+	t.Run("Teste todos os erros", func(t *testing.T) {
+		age := 10
+		start := time.Now()
+		end := start.Add(-time.Hour)
+		a := &account{Name: "Anabela", Email: "ana", Code: "abc", Age: &age, StartsAt: &start, EndsAt: &end}
+
+		var got []string
+		for _, f := range apperror.FieldsOf(e.Validate(a, validator.Create)) {
+			got = append(got, f.Field+" "+f.Code+" "+f.Message)
+		}
+		assert.Equal(t, []string{
+			"name max_length name is too long (maximum is 5 characters)",
+			"email email email is not a valid email",
+			"password required password cannot be empty",
+			"code invalid code must have an even length",
+			"age min age cannot be less than 18",
+			"ends_at invalid ends_at cannot be before starts_at",
+		}, got)
+	})
+
+	t.Run("Teste regras por operação", func(t *testing.T) {
+		a := &account{Name: "Ana"}
+		assert.Nil(t, e.Validate(a, validator.Update))
+		assert.Equal(t, "password cannot be empty", e.Validate(a, validator.Create).Error())
+	})
+
+	t.Run("Teste validação parcial", func(t *testing.T) {
+		a := &account{Name: "Anabela", Password: "ab"}
+		fields := apperror.FieldsOf(e.ValidatePartial(a, validator.Password))
+		assert.Equal(t, 1, len(fields))
+		assert.Equal(t, "password", fields[0].Field)
+		assert.Equal(t, validator.CodeMinLength, fields[0].Code)
+
+		a.Password = ""
+		assert.Equal(t, "password cannot be empty", e.ValidatePartial(a, validator.Password).Error())
+
+		a.Password = "secret"
+		assert.Nil(t, e.ValidatePartial(a, validator.Password))
+	})
+
+	t.Run("Teste regra desconhecida", func(t *testing.T) {
+		assert.Panics(t, func() {
+			validator.NewEngine().Validate(&account{Code: "ab"}, validator.Update)
+		})
+	})
+}
@@ -0,0 +1,94 @@
+package worker
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cristiano-pacheco/go-api/core/logger"
+)
+
+// Job is the function executed on every tick of a worker
+type Job func(now time.Time) error
+
+// Worker runs a job periodically in its own goroutine
+type Worker struct {
+	Name     string
+	interval time.Duration
+	job      Job
+	stop     chan struct{}
+	done     chan struct{}
+	mu       sync.RWMutex
+	started  time.Time
+	lastRun  time.Time
+	lastErr  error
+}
+
+// New constructor
+func New(name string, interval time.Duration, job Job) *Worker {
+	return &Worker{
+		Name:     name,
+		interval: interval,
+		job:      job,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start runs the job right away and then once per interval until Stop is called
+func (w *Worker) Start() {
+	go func() {
+		defer close(w.done)
+
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		w.run()
+		for {
+			select {
+			case <-ticker.C:
+				w.run()
+			case <-w.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop signals the worker to finish and waits for the running job to return
+func (w *Worker) Stop() {
+	close(w.stop)
+	<-w.done
+}
+
+// Status returns when the last run finished and the error it returned, if any
+func (w *Worker) Status() (time.Time, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.lastRun, w.lastErr
+}
+
+// Started returns when the running or the last run started, it is after the
+// time returned by Status while a run is in progress
+func (w *Worker) Started() time.Time {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.started
+}
+
+func (w *Worker) run() {
+	now := time.Now().UTC()
+
+	w.mu.Lock()
+	w.started = now
+	w.mu.Unlock()
+
+	err := w.job(now)
+	if err != nil {
+		logger.Default().Error("worker run failed", "worker", w.Name, "error", err)
+	}
+
+	w.mu.Lock()
+	w.lastRun = time.Now().UTC()
+	w.lastErr = err
+	w.mu.Unlock()
+}
@@ -0,0 +1,38 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ErrMissingIfMatch is returned by IfMatchVersion when the request has no If-Match header
+var ErrMissingIfMatch = errors.New("the If-Match header is required")
+
+// ETag formats the version of a record as an entity tag
+func ETag(version int64) string {
+	return fmt.Sprintf("\"%d\"", version)
+}
+
+// IfMatchVersion returns the record version sent in the If-Match header. The
+// wildcard * matches any version and is returned as 0.
+func IfMatchVersion(r *http.Request) (int64, error) {
+	v := strings.TrimSpace(r.Header.Get("If-Match"))
+	if v == "" {
+		return 0, ErrMissingIfMatch
+	}
+
+	if v == "*" {
+		return 0, nil
+	}
+
+	v = strings.Trim(strings.TrimPrefix(v, "W/"), "\"")
+	version, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("invalid If-Match header %q", r.Header.Get("If-Match"))
+	}
+
+	return version, nil
+}
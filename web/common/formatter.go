@@ -1,20 +0,0 @@
-package common
-
-import "encoding/json"
-
-// FormatJSONError helper
-func FormatJSONError(message string) []byte {
-	appError := struct {
-		Message string `json:"message"`
-	}{
-		message,
-	}
-
-	response, err := json.Marshal(appError)
-
-	if err != nil {
-		return []byte(err.Error())
-	}
-
-	return response
-}
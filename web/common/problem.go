@@ -0,0 +1,110 @@
+package common
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/cristiano-pacheco/go-api/core/apperror"
+	"github.com/cristiano-pacheco/go-api/core/i18n"
+)
+
+// ProblemContentType is the media type of the error responses (RFC 7807)
+const ProblemContentType = "application/problem+json"
+
+// RequestIDHeader is the header that carries the ID of a request
+const RequestIDHeader = "X-Request-ID"
+
+// Problem is the body of an error response. The type is about:blank, so the
+// title is the text of the status and detail tells what went wrong.
+type Problem struct {
+	Type      string                 `json:"type"`
+	Title     string                 `json:"title"`
+	Status    int                    `json:"status"`
+	Detail    string                 `json:"detail,omitempty"`
+	Instance  string                 `json:"instance,omitempty"`
+	RequestID string                 `json:"request_id,omitempty"`
+	Errors    []*apperror.FieldError `json:"errors,omitempty"`
+}
+
+// NewProblem returns the problem of a request
+func NewProblem(r *http.Request, status int, detail string) *Problem {
+	return &Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  r.URL.Path,
+		RequestID: r.Header.Get(RequestIDHeader),
+	}
+}
+
+// NewErrorProblem returns the problem of an error returned by a service. The
+// message and the invalid fields of errors that have a message key are
+// translated to the locale of the request. Internal errors only have the text
+// of the status, their message may come from the database or a driver.
+func NewErrorProblem(r *http.Request, status int, err error) *Problem {
+	if status >= http.StatusInternalServerError {
+		return NewProblem(r, status, http.StatusText(status))
+	}
+
+	p := NewProblem(r, status, err.Error())
+	locale := i18n.FromContext(r.Context())
+
+	for _, f := range apperror.FieldsOf(err) {
+		p.Errors = append(p.Errors, translateField(locale, f))
+	}
+
+	e, ok := err.(*apperror.Error)
+	if !ok {
+		return p
+	}
+
+	if e.Key != "" {
+		if msg, ok := i18n.Message(locale, e.Key, e.Args...); ok {
+			p.Detail = msg
+		}
+	} else if len(e.Fields) > 0 {
+		msgs := make([]string, len(p.Errors))
+		for i, f := range p.Errors {
+			msgs[i] = f.Message
+		}
+		p.Detail = strings.Join(msgs, "; ")
+	}
+
+	return p
+}
+
+func translateField(locale string, f *apperror.FieldError) *apperror.FieldError {
+	if f.Key == "" {
+		return f
+	}
+
+	args := f.Params
+	if f.Field != "" {
+		args = append([]interface{}{f.Field}, f.Params...)
+	}
+
+	msg, ok := i18n.Message(locale, f.Key, args...)
+	if !ok {
+		return f
+	}
+
+	translated := *f
+	translated.Message = msg
+	return &translated
+}
+
+// WriteProblem writes an error response, it must be called before anything
+// else is written to w
+func WriteProblem(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	NewProblem(r, status, detail).Write(w)
+}
+
+// Write writes the problem as the response
+func (p *Problem) Write(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.Header().Del("ETag")
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
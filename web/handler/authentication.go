@@ -36,21 +36,18 @@ func issueToken(service *auth.Service) http.Handler {
 
 		err := json.NewDecoder(r.Body).Decode(&ar)
 		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			w.Write(common.FormatJSONError(err.Error()))
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
 			return
 		}
 		token, err := service.IssueToken(ar.Email, ar.Password)
 		if err != nil {
-			w.WriteHeader(http.StatusUnauthorized)
-			w.Write(common.FormatJSONError(err.Error()))
+			writeError(w, r, err)
 			return
 		}
 
 		err = json.NewEncoder(w).Encode(token)
 		if err != nil {
-			w.Write(common.FormatJSONError(err.Error()))
-			w.WriteHeader(http.StatusInternalServerError)
+			common.WriteProblem(w, r, http.StatusInternalServerError, err.Error())
 			return
 		}
 	})
@@ -59,18 +56,16 @@ func issueToken(service *auth.Service) http.Handler {
 // getAuthenticatedUserData
 func getAuthenticatedUserData(service *auth.Service) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		userId := r.Context().Value("UserID").(int)
-		au, err := service.GetUserPermissionsById(userId)
+		userId, _ := auth.UserIDFromContext(r.Context())
+		au, err := service.GetUserPermissionsById(int(userId))
 		if err != nil {
-			w.Write(common.FormatJSONError(err.Error()))
-			w.WriteHeader(http.StatusInternalServerError)
+			writeError(w, r, err)
 			return
 		}
 
 		err = json.NewEncoder(w).Encode(au)
 		if err != nil {
-			w.Write(common.FormatJSONError(err.Error()))
-			w.WriteHeader(http.StatusInternalServerError)
+			common.WriteProblem(w, r, http.StatusInternalServerError, err.Error())
 			return
 		}
 	})
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/cristiano-pacheco/go-api/core/apperror"
+	"github.com/cristiano-pacheco/go-api/web/common"
+)
+
+// ifMatchVersion reads the version a PUT is based on. When the If-Match header
+// is missing or invalid the error response is written and false returned.
+func ifMatchVersion(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	version, err := common.IfMatchVersion(r)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, common.ErrMissingIfMatch) {
+			status = http.StatusPreconditionRequired
+		}
+		common.WriteProblem(w, r, status, err.Error())
+		return 0, false
+	}
+
+	return version, true
+}
+
+// updateErrorStatus returns the status of an update error, a version conflict
+// means the If-Match precondition failed
+func updateErrorStatus(err error) int {
+	var conflict *apperror.VersionConflictError
+	if errors.As(err, &conflict) {
+		return http.StatusPreconditionFailed
+	}
+
+	return errorStatus(err)
+}
+
+// writeUpdateError writes the problem of an error returned by an update
+func writeUpdateError(w http.ResponseWriter, r *http.Request, err error) {
+	writeErrorStatus(w, r, updateErrorStatus(err), err)
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/cristiano-pacheco/go-api/core/apperror"
+	"github.com/cristiano-pacheco/go-api/core/logger"
+	"github.com/cristiano-pacheco/go-api/web/common"
+)
+
+// errorStatus returns the status of an error returned by a service, errors
+// without a kind are internal errors
+func errorStatus(err error) int {
+	switch apperror.KindOf(err) {
+	case apperror.NotFoundKind:
+		return http.StatusNotFound
+	case apperror.ConflictKind:
+		return http.StatusConflict
+	case apperror.ValidationKind:
+		return http.StatusBadRequest
+	case apperror.ForbiddenKind:
+		return http.StatusForbidden
+	case apperror.UnauthenticatedKind:
+		return http.StatusUnauthorized
+	}
+
+	return http.StatusInternalServerError
+}
+
+// writeError writes the problem of an error returned by a service, with the
+// invalid fields of validation errors
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	writeErrorStatus(w, r, errorStatus(err), err)
+}
+
+// writeErrorStatus writes the problem of an error with a status, internal
+// errors are logged since their cause is not something the client can fix and
+// the client only gets the text of the status
+func writeErrorStatus(w http.ResponseWriter, r *http.Request, status int, err error) {
+	if status >= http.StatusInternalServerError {
+		logger.FromContext(r.Context()).Error("request failed", "error", err)
+	}
+
+	common.NewErrorProblem(r, status, err).Write(w)
+}
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cristiano-pacheco/go-api/core/apperror"
+	"github.com/cristiano-pacheco/go-api/web/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteError(t *testing.T) {
+	write := func(err error) *common.Problem {
+		rec := httptest.NewRecorder()
+		writeError(rec, httptest.NewRequest(http.MethodGet, "/v1/lists/1", nil), err)
+
+		var p common.Problem
+		json.NewDecoder(rec.Body).Decode(&p)
+		return &p
+	}
+
+	t.Run("Teste erro interno sem detalhes", func(t *testing.T) {
+		p := write(errors.New("Error 1146: Table 'go_api.list' doesn't exist"))
+		assert.Equal(t, http.StatusInternalServerError, p.Status)
+		assert.Equal(t, http.StatusText(http.StatusInternalServerError), p.Detail)
+	})
+
+	t.Run("Teste erro do cliente com mensagem", func(t *testing.T) {
+		p := write(apperror.NotFound("list 1 not found"))
+		assert.Equal(t, http.StatusNotFound, p.Status)
+		assert.Equal(t, "list 1 not found", p.Detail)
+	})
+}
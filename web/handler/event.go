@@ -0,0 +1,237 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cristiano-pacheco/go-api/core/apperror"
+	"github.com/cristiano-pacheco/go-api/core/auth"
+	"github.com/cristiano-pacheco/go-api/core/event"
+	"github.com/cristiano-pacheco/go-api/core/list"
+	"github.com/cristiano-pacheco/go-api/web/common"
+	"github.com/cristiano-pacheco/go-api/web/middleware"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/urfave/negroni"
+)
+
+const (
+	// sseStreamDuration must stay below the server WriteTimeout, the stream is
+	// closed before it and EventSource reconnects sending the Last-Event-ID
+	sseStreamDuration = 25 * time.Second
+	sseRetry          = 3 * time.Second
+	keepAliveInterval = 15 * time.Second
+	wsWriteWait       = 10 * time.Second
+	wsPongWait        = 2 * keepAliveInterval
+)
+
+var upgrader = websocket.Upgrader{
+	// the API accepts requests from any origin, see the CORS options
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// MakeEventHandlers create the list event stream handlers
+func MakeEventHandlers(r *mux.Router, n *negroni.Negroni, broker *event.Broker, service list.UseCase, authService *auth.Service) {
+	r.Handle("/v1/lists/{id}/events", n.With(
+		middleware.TokenFromQuery(),
+		middleware.CheckAuthentication(authService),
+		negroni.Wrap(streamListEvents(broker, service)),
+	)).Methods("GET", "OPTIONS").Name(auth.GetListEventsAction)
+}
+
+// streamListEvents streams the changes of a list with Server-Sent Events, or over
+// a WebSocket when the request asks for an upgrade. Clients resume from the
+// Last-Event-ID header or the last_event_id query parameter.
+func streamListEvents(broker *event.Broker, service list.UseCase) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := strconv.ParseInt(vars["id"], 10, 64)
+		if err != nil {
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		// the broker subscribes to every list with the ID 0
+		if id < 1 {
+			writeError(w, r, apperror.New(apperror.ValidationKind, "invalid_id", "invalid ID"))
+			return
+		}
+
+		_, err = service.Get(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		lastEventID, err := parseLastEventID(r)
+		if err != nil {
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if websocket.IsWebSocketUpgrade(r) {
+			serveWebSocket(w, r, broker, id, lastEventID)
+			return
+		}
+
+		serveSSE(w, r, broker, id, lastEventID)
+	})
+}
+
+func parseLastEventID(r *http.Request) (int64, error) {
+	v := r.Header.Get("Last-Event-ID")
+	if v == "" {
+		v = r.URL.Query().Get("last_event_id")
+	}
+
+	if v == "" {
+		return 0, nil
+	}
+
+	return strconv.ParseInt(v, 10, 64)
+}
+
+// pendingEvents returns the events to send right after subscribing, starting
+// with a reset when the subscriber missed events that can't be replayed
+func pendingEvents(broker *event.Broker, listID int64, replay []*event.Event, complete bool) []*event.Event {
+	if complete {
+		return replay
+	}
+
+	reset := &event.Event{
+		ID:        broker.LastID(),
+		Type:      event.Reset,
+		ListID:    listID,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	return []*event.Event{reset}
+}
+
+func serveSSE(w http.ResponseWriter, r *http.Request, broker *event.Broker, listID, lastEventID int64) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		common.WriteProblem(w, r, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+
+	sub, replay, complete := broker.Subscribe(listID, lastEventID)
+	defer sub.Unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", sseRetry.Milliseconds())
+	for _, e := range pendingEvents(broker, listID, replay, complete) {
+		if writeSSE(w, e) != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	timeout := time.NewTimer(sseStreamDuration)
+	defer timeout.Stop()
+
+	for {
+		select {
+		case e, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			if writeSSE(w, e) != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case <-timeout.C:
+			return
+		case <-r.Context().Done():
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+func writeSSE(w http.ResponseWriter, e *event.Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+	return err
+}
+
+func serveWebSocket(w http.ResponseWriter, r *http.Request, broker *event.Broker, listID, lastEventID int64) {
+	// Upgrade replies with the error itself
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	defer conn.Close()
+
+	sub, replay, complete := broker.Subscribe(listID, lastEventID)
+	defer sub.Unsubscribe()
+
+	// the deadlines set by the server timeouts are replaced by the ping/pong ones
+	conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	// the read loop handles the control frames and detects the client leaving
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	send := func(e *event.Event) error {
+		conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+		return conn.WriteJSON(e)
+	}
+
+	for _, e := range pendingEvents(broker, listID, replay, complete) {
+		if send(e) != nil {
+			return
+		}
+	}
+
+	ping := time.NewTicker(keepAliveInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case e, ok := <-sub.C:
+			if !ok {
+				conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, ""), time.Now().Add(wsWriteWait))
+				return
+			}
+			if send(e) != nil {
+				return
+			}
+		case <-ping.C:
+			if conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait)) != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cristiano-pacheco/go-api/core/auth"
+	"github.com/cristiano-pacheco/go-api/core/event"
+	"github.com/cristiano-pacheco/go-api/core/list"
+	"github.com/gbrlsnchs/jwt/v3"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/negroni"
+)
+
+func TestTokenFromQuery(t *testing.T) {
+	authService := &auth.Service{
+		JWTHash:  jwt.NewHS256([]byte("secret")),
+		Failures: prometheus.NewCounterVec(prometheus.CounterOpts{Name: "failures", Help: "Failures."}, []string{"reason"}),
+	}
+
+	r := mux.NewRouter()
+	n := negroni.New()
+	listService := &list.Service{}
+	MakeListHandlers(r, n, listService, authService)
+	MakeEventHandlers(r, n, event.NewBroker(10), listService, authService)
+
+	get := func(url string) int {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+		return rec.Code
+	}
+
+	t.Run("Teste token na query ignorado fora dos eventos", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, get("/v1/lists?access_token=abc"))
+		assert.Equal(t, float64(1), testutil.ToFloat64(authService.Failures.WithLabelValues(auth.FailureMissingToken)))
+	})
+
+	t.Run("Teste token na query aceito nos eventos", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, get("/v1/lists/1/events?access_token=abc"))
+		assert.Equal(t, float64(1), testutil.ToFloat64(authService.Failures.WithLabelValues(auth.FailureInvalidToken)))
+	})
+}
+
+func TestStreamListEventsInvalidID(t *testing.T) {
+	h := streamListEvents(event.NewBroker(10), &list.Service{})
+
+	for _, id := range []string{"0", "-1", "abc"} {
+		rec := httptest.NewRecorder()
+		req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/v1/lists/"+id+"/events", nil), map[string]string{"id": id})
+		h.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, id)
+	}
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/cristiano-pacheco/go-api/core/health"
+	"github.com/gorilla/mux"
+	"github.com/urfave/negroni"
+)
+
+// MakeHealthHandlers serves the liveness probe, which only tells the process
+// is up, and the readiness probe, which runs the checks of the checker
+func MakeHealthHandlers(r *mux.Router, n *negroni.Negroni, checker *health.Checker) {
+	r.Handle("/healthz", n.With(
+		negroni.Wrap(getHealth()),
+	)).Methods("GET", "OPTIONS")
+
+	r.Handle("/readyz", n.With(
+		negroni.Wrap(getReadiness(checker)),
+	)).Methods("GET", "OPTIONS")
+}
+
+func getHealth() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeReport(w, &health.Report{Status: health.StatusOK, Checks: []*health.Result{}})
+	})
+}
+
+func getReadiness(checker *health.Checker) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeReport(w, checker.Run(r.Context()))
+	})
+}
+
+func writeReport(w http.ResponseWriter, report *health.Report) {
+	w.Header().Set("Cache-Control", "no-store")
+	if report.Status != health.StatusOK {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	json.NewEncoder(w).Encode(report)
+}
@@ -4,9 +4,12 @@ import (
 	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
 
+	"github.com/cristiano-pacheco/go-api/core/apperror"
 	"github.com/cristiano-pacheco/go-api/core/auth"
 	"github.com/cristiano-pacheco/go-api/core/list"
+	"github.com/cristiano-pacheco/go-api/core/query"
 	"github.com/cristiano-pacheco/go-api/web/common"
 	"github.com/cristiano-pacheco/go-api/web/middleware"
 	"github.com/gorilla/mux"
@@ -15,6 +18,37 @@ import (
 
 // MakeListHandlers create all resource handlers
 func MakeListHandlers(r *mux.Router, n *negroni.Negroni, service list.UseCase, authService *auth.Service) {
+	// registered before /v1/lists/{id} so "stats" and "trash" are not taken as ids
+	r.Handle("/v1/lists/stats", n.With(
+		middleware.CheckAuthentication(authService),
+		negroni.Wrap(getAllListStats(service)),
+	)).Methods("GET", "OPTIONS").Name(auth.GetAllListStatsAction)
+
+	r.Handle("/v1/lists/{id}/stats", n.With(
+		middleware.CheckAuthentication(authService),
+		negroni.Wrap(getListStats(service)),
+	)).Methods("GET", "OPTIONS").Name(auth.GetListStatsAction)
+
+	r.Handle("/v1/lists/trash", n.With(
+		middleware.CheckAuthentication(authService),
+		negroni.Wrap(getListTrash(service)),
+	)).Methods("GET", "OPTIONS").Name(auth.GetListTrashAction)
+
+	r.Handle("/v1/lists/trash/{id}/restore", n.With(
+		middleware.CheckAuthentication(authService),
+		negroni.Wrap(restoreList(service)),
+	)).Methods("POST", "OPTIONS").Name(auth.RestoreListAction)
+
+	r.Handle("/v1/lists/{id}/items/trash", n.With(
+		middleware.CheckAuthentication(authService),
+		negroni.Wrap(getListItemTrash(service)),
+	)).Methods("GET", "OPTIONS").Name(auth.GetListItemTrashAction)
+
+	r.Handle("/v1/lists/{id}/items/trash/{itemId}/restore", n.With(
+		middleware.CheckAuthentication(authService),
+		negroni.Wrap(restoreListItem(service)),
+	)).Methods("POST", "OPTIONS").Name(auth.RestoreListItemAction)
+
 	r.Handle("/v1/lists", n.With(
 		middleware.CheckAuthentication(authService),
 		negroni.Wrap(getAllLists(service)),
@@ -35,6 +69,11 @@ func MakeListHandlers(r *mux.Router, n *negroni.Negroni, service list.UseCase, a
 		negroni.Wrap(updateList(service)),
 	)).Methods("PUT", "OPTIONS").Name(auth.UpdateListAction)
 
+	r.Handle("/v1/lists/{id}", n.With(
+		middleware.CheckAuthentication(authService),
+		negroni.Wrap(patchList(service)),
+	)).Methods("PATCH", "OPTIONS").Name(auth.PatchListAction)
+
 	r.Handle("/v1/lists/{id}", n.With(
 		middleware.CheckAuthentication(authService),
 		negroni.Wrap(removeList(service)),
@@ -44,6 +83,11 @@ func MakeListHandlers(r *mux.Router, n *negroni.Negroni, service list.UseCase, a
 	r.Handle("/v1/lists/{id}/items", n.With(
 		middleware.CheckAuthentication(authService),
 		negroni.Wrap(getAllListItems(service)),
+	)).Methods("GET", "OPTIONS").Name(auth.GetAllListItemsAction)
+
+	r.Handle("/v1/lists/{id}/items/{itemId}", n.With(
+		middleware.CheckAuthentication(authService),
+		negroni.Wrap(getListItem(service)),
 	)).Methods("GET", "OPTIONS").Name(auth.GetListItemAction)
 
 	r.Handle("/v1/lists/{id}/items", n.With(
@@ -56,25 +100,84 @@ func MakeListHandlers(r *mux.Router, n *negroni.Negroni, service list.UseCase, a
 		negroni.Wrap(updateListItem(service)),
 	)).Methods("PUT", "OPTIONS").Name(auth.UpdateListItemAction)
 
-	r.Handle("/v1/users/{id}/items/{itemId}", n.With(
+	r.Handle("/v1/lists/{id}/items/{itemId}", n.With(
+		middleware.CheckAuthentication(authService),
+		negroni.Wrap(patchListItem(service)),
+	)).Methods("PATCH", "OPTIONS").Name(auth.PatchListItemAction)
+
+	r.Handle("/v1/lists/{id}/items:batch", n.With(
+		middleware.CheckAuthentication(authService),
+		negroni.Wrap(batchListItems(service)),
+	)).Methods("POST", "OPTIONS").Name(auth.BatchListItemsAction)
+
+	r.Handle("/v1/lists/{id}/items/{itemId}", n.With(
 		middleware.CheckAuthentication(authService),
 		negroni.Wrap(removeListItem(service)),
 	)).Methods("DELETE", "OPTIONS").Name(auth.RemoveListItemAction)
+
+	// history routes
+	r.Handle("/v1/lists/{id}/history", n.With(
+		middleware.CheckAuthentication(authService),
+		negroni.Wrap(getListHistory(service)),
+	)).Methods("GET", "OPTIONS").Name(auth.GetListHistoryAction)
+
+	r.Handle("/v1/lists/{id}/history/{revisionId}/restore", n.With(
+		middleware.CheckAuthentication(authService),
+		negroni.Wrap(restoreListRevision(service)),
+	)).Methods("POST", "OPTIONS").Name(auth.RestoreListRevisionAction)
+
+	// tag routes
+	r.Handle("/v1/lists/{id}/items/{itemId}/tags", n.With(
+		middleware.CheckAuthentication(authService),
+		negroni.Wrap(updateListItemTags(service)),
+	)).Methods("PUT", "OPTIONS").Name(auth.UpdateListItemTagsAction)
+
+	r.Handle("/v1/tags", n.With(
+		middleware.CheckAuthentication(authService),
+		negroni.Wrap(getAllTags(service)),
+	)).Methods("GET", "OPTIONS").Name(auth.GetAllTagsAction)
+
+	r.Handle("/v1/items", n.With(
+		middleware.CheckAuthentication(authService),
+		negroni.Wrap(getAllItemsByTags(service)),
+	)).Methods("GET", "OPTIONS").Name(auth.GetAllItemsByTagsAction)
 }
 
 func getAllLists(service list.UseCase) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		all, err := service.GetAll()
+		p, err := query.Parse(r.URL.Query(), list.ListQuerySpec)
 		if err != nil {
-			w.Write(common.FormatJSONError(err.Error()))
-			w.WriteHeader(http.StatusInternalServerError)
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
 			return
 		}
 
-		err = json.NewEncoder(w).Encode(all)
+		expand, err := list.ParseExpand(r.URL.Query().Get("expand"))
+		if err != nil {
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		all, res, err := service.GetAll(p)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		err = service.Embed(all, expand)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		data, err := query.ParseFields(r.URL.Query().Get("fields")).Select(all)
+		if err != nil {
+			common.WriteProblem(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		err = json.NewEncoder(w).Encode(query.NewPage(data, res))
 		if err != nil {
-			w.Write(common.FormatJSONError(err.Error()))
-			w.WriteHeader(http.StatusInternalServerError)
+			common.WriteProblem(w, r, http.StatusInternalServerError, err.Error())
 			return
 		}
 	})
@@ -86,22 +189,38 @@ func getList(service list.UseCase) http.Handler {
 
 		id, err := strconv.ParseInt(vars["id"], 10, 64)
 		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			w.Write(common.FormatJSONError(err.Error()))
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		expand, err := list.ParseExpand(r.URL.Query().Get("expand"))
+		if err != nil {
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
 			return
 		}
 
 		u, err := service.Get(id)
 		if err != nil {
-			w.Write(common.FormatJSONError(err.Error()))
-			w.WriteHeader(http.StatusInternalServerError)
+			writeError(w, r, err)
 			return
 		}
 
-		err = json.NewEncoder(w).Encode(u)
+		err = service.Embed([]*list.List{u}, expand)
 		if err != nil {
-			w.Write(common.FormatJSONError(err.Error()))
-			w.WriteHeader(http.StatusInternalServerError)
+			writeError(w, r, err)
+			return
+		}
+
+		data, err := query.ParseFields(r.URL.Query().Get("fields")).Select(u)
+		if err != nil {
+			common.WriteProblem(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		w.Header().Set("ETag", common.ETag(u.Version))
+		err = json.NewEncoder(w).Encode(data)
+		if err != nil {
+			common.WriteProblem(w, r, http.StatusInternalServerError, err.Error())
 			return
 		}
 	})
@@ -113,15 +232,13 @@ func storeList(service list.UseCase) http.Handler {
 
 		err := json.NewDecoder(r.Body).Decode(&l)
 		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			w.Write(common.FormatJSONError(err.Error()))
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
 			return
 		}
 
-		err = service.Store(&l)
+		err = service.WithContext(r.Context()).Store(&l)
 		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			w.Write(common.FormatJSONError(err.Error()))
+			writeError(w, r, err)
 			return
 		}
 		w.WriteHeader(http.StatusCreated)
@@ -134,8 +251,12 @@ func updateList(service list.UseCase) http.Handler {
 
 		id, err := strconv.ParseInt(vars["id"], 10, 64)
 		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			w.Write(common.FormatJSONError(err.Error()))
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		version, ok := ifMatchVersion(w, r)
+		if !ok {
 			return
 		}
 
@@ -143,19 +264,19 @@ func updateList(service list.UseCase) http.Handler {
 
 		err = json.NewDecoder(r.Body).Decode(&l)
 		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			w.Write(common.FormatJSONError(err.Error()))
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
 			return
 		}
 
 		l.ID = id
-		err = service.Update(&l)
+		l.Version = version
+		err = service.WithContext(r.Context()).Update(&l)
 		if err != nil {
-			w.Write(common.FormatJSONError(err.Error()))
-			w.WriteHeader(http.StatusInternalServerError)
+			writeUpdateError(w, r, err)
 			return
 		}
 
+		w.Header().Set("ETag", common.ETag(l.Version))
 		w.WriteHeader(http.StatusOK)
 	})
 }
@@ -166,15 +287,13 @@ func removeList(service list.UseCase) http.Handler {
 
 		id, err := strconv.ParseInt(vars["id"], 10, 64)
 		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			w.Write(common.FormatJSONError(err.Error()))
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
 			return
 		}
 
-		err = service.Remove(id)
+		err = service.WithContext(r.Context()).Remove(id)
 		if err != nil {
-			w.Write(common.FormatJSONError(err.Error()))
-			w.WriteHeader(http.StatusInternalServerError)
+			writeError(w, r, err)
 			return
 		}
 
@@ -188,22 +307,76 @@ func getAllListItems(service list.UseCase) http.Handler {
 
 		id, err := strconv.ParseInt(vars["id"], 10, 64)
 		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			w.Write(common.FormatJSONError(err.Error()))
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
 			return
 		}
 
-		all, err := service.GetAllItems(id)
+		p, err := query.Parse(r.URL.Query(), list.ItemQuerySpec)
 		if err != nil {
-			w.Write(common.FormatJSONError(err.Error()))
-			w.WriteHeader(http.StatusInternalServerError)
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
 			return
 		}
 
-		err = json.NewEncoder(w).Encode(all)
+		all, res, err := service.GetAllItems(id, p)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		data, err := query.ParseFields(r.URL.Query().Get("fields")).Select(all)
 		if err != nil {
-			w.Write(common.FormatJSONError(err.Error()))
-			w.WriteHeader(http.StatusInternalServerError)
+			common.WriteProblem(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		err = json.NewEncoder(w).Encode(query.NewPage(data, res))
+		if err != nil {
+			common.WriteProblem(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+	})
+}
+
+// getItemOfList loads an item, items of another list are not found
+func getItemOfList(service list.UseCase, listID, itemID int64) (*list.ListItem, error) {
+	li, err := service.GetItem(itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	if li.ListID != listID {
+		return nil, apperror.NotFound("list item not found")
+	}
+
+	return li, nil
+}
+
+func getListItem(service list.UseCase) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := strconv.ParseInt(vars["id"], 10, 64)
+		if err != nil {
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		itemId, err := strconv.ParseInt(vars["itemId"], 10, 64)
+		if err != nil {
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		li, err := getItemOfList(service, id, itemId)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		w.Header().Set("ETag", common.ETag(li.Version))
+		err = json.NewEncoder(w).Encode(li)
+		if err != nil {
+			common.WriteProblem(w, r, http.StatusInternalServerError, err.Error())
 			return
 		}
 	})
@@ -215,8 +388,7 @@ func storeListItem(service list.UseCase) http.Handler {
 
 		err := json.NewDecoder(r.Body).Decode(&li)
 		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			w.Write(common.FormatJSONError(err.Error()))
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
 			return
 		}
 
@@ -224,17 +396,15 @@ func storeListItem(service list.UseCase) http.Handler {
 
 		id, err := strconv.ParseInt(vars["id"], 10, 64)
 		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			w.Write(common.FormatJSONError(err.Error()))
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
 			return
 		}
 
 		li.ListID = id
 
-		err = service.StoreItem(&li)
+		err = service.WithContext(r.Context()).StoreItem(&li)
 		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			w.Write(common.FormatJSONError(err.Error()))
+			writeError(w, r, err)
 			return
 		}
 		w.WriteHeader(http.StatusCreated)
@@ -247,15 +417,18 @@ func updateListItem(service list.UseCase) http.Handler {
 
 		id, err := strconv.ParseInt(vars["id"], 10, 64)
 		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			w.Write(common.FormatJSONError(err.Error()))
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
 			return
 		}
 
 		itemId, err := strconv.ParseInt(vars["itemId"], 10, 64)
 		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			w.Write(common.FormatJSONError(err.Error()))
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		version, ok := ifMatchVersion(w, r)
+		if !ok {
 			return
 		}
 
@@ -263,20 +436,20 @@ func updateListItem(service list.UseCase) http.Handler {
 
 		err = json.NewDecoder(r.Body).Decode(&li)
 		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			w.Write(common.FormatJSONError(err.Error()))
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
 			return
 		}
 
 		li.ID = itemId
 		li.ListID = id
-		err = service.UpdateItem(&li)
+		li.Version = version
+		err = service.WithContext(r.Context()).UpdateItem(&li)
 		if err != nil {
-			w.Write(common.FormatJSONError(err.Error()))
-			w.WriteHeader(http.StatusInternalServerError)
+			writeUpdateError(w, r, err)
 			return
 		}
 
+		w.Header().Set("ETag", common.ETag(li.Version))
 		w.WriteHeader(http.StatusOK)
 	})
 }
@@ -285,20 +458,429 @@ func removeListItem(service list.UseCase) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		vars := mux.Vars(r)
 
+		id, err := strconv.ParseInt(vars["id"], 10, 64)
+		if err != nil {
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
 		itemId, err := strconv.ParseInt(vars["itemId"], 10, 64)
 		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			w.Write(common.FormatJSONError(err.Error()))
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		_, err = getItemOfList(service, id, itemId)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		err = service.WithContext(r.Context()).RemoveItem(itemId)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+type tagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+func updateListItemTags(service list.UseCase) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		itemId, err := strconv.ParseInt(vars["itemId"], 10, 64)
+		if err != nil {
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		var tr tagsRequest
+
+		err = json.NewDecoder(r.Body).Decode(&tr)
+		if err != nil {
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		userId, _ := auth.UserIDFromContext(r.Context())
+		err = service.WithContext(r.Context()).SetItemTags(itemId, userId, tr.Tags)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+// getAllTags autocomplete the tags of the authenticated user, filtered by the q prefix
+func getAllTags(service list.UseCase) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userId, _ := auth.UserIDFromContext(r.Context())
+		all, err := service.GetTags(userId, r.URL.Query().Get("q"))
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		err = json.NewEncoder(w).Encode(all)
+		if err != nil {
+			common.WriteProblem(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+	})
+}
+
+// getAllItemsByTags filter the items by the comma separated tags, match=all
+// requires every tag to be present while the default matches any of them
+func getAllItemsByTags(service list.UseCase) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+
+		tags := strings.Split(q.Get("tags"), ",")
+		if strings.TrimSpace(q.Get("tags")) == "" {
+			common.WriteProblem(w, r, http.StatusBadRequest, "tags cannot be empty")
+			return
+		}
+
+		match := q.Get("match")
+		if match != "" && match != "any" && match != "all" {
+			common.WriteProblem(w, r, http.StatusBadRequest, "match must be any or all")
+			return
+		}
+
+		userId, _ := auth.UserIDFromContext(r.Context())
+		all, err := service.GetItemsByTags(userId, tags, match == "all")
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		err = json.NewEncoder(w).Encode(all)
+		if err != nil {
+			common.WriteProblem(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+	})
+}
+
+func getAllListStats(service list.UseCase) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		all, err := service.GetAllStats()
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		err = json.NewEncoder(w).Encode(all)
+		if err != nil {
+			common.WriteProblem(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+	})
+}
+
+func getListStats(service list.UseCase) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := strconv.ParseInt(vars["id"], 10, 64)
+		if err != nil {
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		st, err := service.GetStats(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		err = json.NewEncoder(w).Encode(st)
+		if err != nil {
+			common.WriteProblem(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+	})
+}
+
+func getListHistory(service list.UseCase) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := strconv.ParseInt(vars["id"], 10, 64)
+		if err != nil {
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		all, err := service.GetHistory(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		err = json.NewEncoder(w).Encode(all)
+		if err != nil {
+			common.WriteProblem(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+	})
+}
+
+func restoreListRevision(service list.UseCase) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := strconv.ParseInt(vars["id"], 10, 64)
+		if err != nil {
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		revisionId, err := strconv.ParseInt(vars["revisionId"], 10, 64)
+		if err != nil {
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		rev, err := service.WithContext(r.Context()).Restore(id, revisionId)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(rev)
+	})
+}
+
+func getListTrash(service list.UseCase) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		all, err := service.GetTrash()
+		if err != nil {
+			writeError(w, r, err)
 			return
 		}
 
-		err = service.Remove(itemId)
+		err = json.NewEncoder(w).Encode(all)
 		if err != nil {
-			w.Write(common.FormatJSONError(err.Error()))
-			w.WriteHeader(http.StatusInternalServerError)
+			common.WriteProblem(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+	})
+}
+
+func restoreList(service list.UseCase) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := strconv.ParseInt(vars["id"], 10, 64)
+		if err != nil {
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		err = service.WithContext(r.Context()).RestoreFromTrash(id)
+		if err != nil {
+			writeError(w, r, err)
 			return
 		}
 
 		w.WriteHeader(http.StatusNoContent)
 	})
 }
+
+func getListItemTrash(service list.UseCase) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := strconv.ParseInt(vars["id"], 10, 64)
+		if err != nil {
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		all, err := service.GetItemTrash(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		err = json.NewEncoder(w).Encode(all)
+		if err != nil {
+			common.WriteProblem(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+	})
+}
+
+func restoreListItem(service list.UseCase) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := strconv.ParseInt(vars["itemId"], 10, 64)
+		if err != nil {
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		err = service.WithContext(r.Context()).RestoreItemFromTrash(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// maxBatchOperations limits how many operations a batch request may carry
+const maxBatchOperations = 500
+
+type batchRequest struct {
+	Operations []*list.BatchOperation `json:"operations"`
+}
+
+type batchResponse struct {
+	Message string              `json:"message,omitempty"`
+	Results []*list.BatchResult `json:"results"`
+}
+
+func batchListItems(service list.UseCase) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := strconv.ParseInt(vars["id"], 10, 64)
+		if err != nil {
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		var req batchRequest
+		err = json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if len(req.Operations) == 0 || len(req.Operations) > maxBatchOperations {
+			common.WriteProblem(w, r, http.StatusBadRequest, "a batch must have between 1 and 500 operations")
+			return
+		}
+
+		results, err := service.WithContext(r.Context()).ExecBatch(id, req.Operations)
+		if results == nil && err != nil {
+			common.WriteProblem(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		res := &batchResponse{Results: results}
+		status := http.StatusOK
+		if err != nil {
+			res.Message = err.Error()
+			status = errorStatus(err)
+			if err == list.ErrInvalidBatch {
+				status = http.StatusUnprocessableEntity
+			}
+		}
+
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(res)
+	})
+}
+
+func patchList(service list.UseCase) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := strconv.ParseInt(vars["id"], 10, 64)
+		if err != nil {
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		current, err := service.Get(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		version, ok := patchVersion(w, r, current.Version)
+		if !ok {
+			return
+		}
+
+		var l list.List
+		if !applyPatch(w, r, current, &l) {
+			return
+		}
+
+		l.ID = id
+		l.Version = version
+		err = service.WithContext(r.Context()).Update(&l)
+		if err != nil {
+			writeUpdateError(w, r, err)
+			return
+		}
+
+		w.Header().Set("ETag", common.ETag(l.Version))
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(&l)
+	})
+}
+
+func patchListItem(service list.UseCase) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := strconv.ParseInt(vars["id"], 10, 64)
+		if err != nil {
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		itemId, err := strconv.ParseInt(vars["itemId"], 10, 64)
+		if err != nil {
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		current, err := getItemOfList(service, id, itemId)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		version, ok := patchVersion(w, r, current.Version)
+		if !ok {
+			return
+		}
+
+		// tags are changed through their own endpoint
+		var li list.ListItem
+		if !applyPatch(w, r, current, &li) {
+			return
+		}
+
+		li.ID = itemId
+		li.ListID = id
+		li.Version = version
+		err = service.WithContext(r.Context()).UpdateItem(&li)
+		if err != nil {
+			writeUpdateError(w, r, err)
+			return
+		}
+
+		li.Tags = current.Tags
+		w.Header().Set("ETag", common.ETag(li.Version))
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(&li)
+	})
+}
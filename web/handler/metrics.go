@@ -0,0 +1,22 @@
+package handler
+
+import (
+	"github.com/cristiano-pacheco/go-api/web/middleware"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/urfave/negroni"
+)
+
+// MakeMetricsHandlers serves the metrics of the registry in the Prometheus
+// text format. The route requires the bearer token when it is not empty, it
+// can be empty when the router is served on a listener of its own.
+func MakeMetricsHandlers(r *mux.Router, n *negroni.Negroni, reg *prometheus.Registry, token string) {
+	handlers := []negroni.Handler{}
+	if token != "" {
+		handlers = append(handlers, middleware.CheckToken(token))
+	}
+	handlers = append(handlers, negroni.Wrap(promhttp.HandlerFor(reg, promhttp.HandlerOpts{})))
+
+	r.Handle("/metrics", n.With(handlers...)).Methods("GET", "OPTIONS")
+}
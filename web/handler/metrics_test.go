@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cristiano-pacheco/go-api/core/metrics"
+	"github.com/cristiano-pacheco/go-api/web/middleware"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/negroni"
+)
+
+func TestMetrics(t *testing.T) {
+	reg := metrics.NewRegistry(nil)
+	r := mux.NewRouter()
+	n := negroni.New(middleware.Metrics(reg))
+	MakeMetricsHandlers(r, n, reg, "secret")
+
+	get := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("Teste token obrigatório", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, get("").Code)
+		assert.Equal(t, http.StatusUnauthorized, get("wrong").Code)
+	})
+
+	t.Run("Teste requisições contadas por rota", func(t *testing.T) {
+		rec := get("secret")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, metrics.ContentType, rec.Header().Get("Content-Type"))
+		assert.Contains(t, rec.Body.String(), `goapi_http_requests_total{method="GET",route="/metrics",status="401"} 2`)
+		assert.Contains(t, rec.Body.String(), `goapi_http_request_duration_seconds_count{method="GET",route="/metrics"} 2`)
+	})
+}
@@ -0,0 +1,217 @@
+package handler
+
+import (
+	_ "embed" // docs.html
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/cristiano-pacheco/go-api/core/auth"
+	"github.com/cristiano-pacheco/go-api/core/event"
+	"github.com/cristiano-pacheco/go-api/core/health"
+	"github.com/cristiano-pacheco/go-api/core/list"
+	"github.com/cristiano-pacheco/go-api/core/metrics"
+	"github.com/cristiano-pacheco/go-api/core/query"
+	"github.com/cristiano-pacheco/go-api/core/recurrence"
+	"github.com/cristiano-pacheco/go-api/core/reminder"
+	"github.com/cristiano-pacheco/go-api/core/user"
+	"github.com/cristiano-pacheco/go-api/web/common"
+	"github.com/cristiano-pacheco/go-api/web/openapi"
+	"github.com/gorilla/mux"
+	"github.com/urfave/negroni"
+)
+
+//go:embed docs.html
+var docsPage []byte
+
+var apiInfo = &openapi.Info{
+	Title:   "go-api",
+	Version: "1.0.0",
+	Description: "Shopping lists API. Every /v1 route but /v1/auth requires a bearer token " +
+		"and the permission named by x-permission. Errors are problem details (RFC 7807).",
+}
+
+// the response envelopes of the paginated collections
+type listPage struct {
+	Data       []*list.List `json:"data"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+	Total      int          `json:"total"`
+}
+
+type listItemPage struct {
+	Data       []*list.ListItem `json:"data"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+	Total      int              `json:"total"`
+}
+
+type userPage struct {
+	Data       []*user.User `json:"data"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+	Total      int          `json:"total"`
+}
+
+// pageParams documents the pagination, sorting and filtering parameters of a collection
+func pageParams(spec *query.Spec) []*openapi.Parameter {
+	params := []*openapi.Parameter{
+		openapi.QueryParam("limit", "integer", "page size, at most 200"),
+		openapi.QueryParam("cursor", "string", "next_cursor of the previous page"),
+		openapi.QueryParam("offset", "integer", "rows to skip, cannot be used with cursor"),
+		openapi.QueryParam("sort", "string", "comma separated fields, a leading minus sorts in descending order"),
+	}
+	if spec.SearchColumn != "" {
+		params = append(params, openapi.QueryParam("q", "string", "text the name contains"))
+	}
+
+	var names []string
+	for name, f := range spec.Fields {
+		if f.Filterable {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	kinds := map[query.Kind]string{query.String: "string", query.Int: "integer", query.Bool: "boolean", query.Time: "string"}
+	for _, name := range names {
+		params = append(params, openapi.QueryParam(name, kinds[spec.Fields[name].Kind], "filter, the value must be equal"))
+	}
+
+	return params
+}
+
+var listParams = []*openapi.Parameter{
+	openapi.QueryParam("expand", "string", "items and items.category embed the items of the lists"),
+	openapi.QueryParam("fields", "string", "comma separated fields to return, nested fields after a dot, e.g. id,name,items.id,items.name"),
+}
+
+// apiRoutes documents every route by method and path
+var apiRoutes = map[string]*openapi.Route{
+	"POST /v1/auth":     {Summary: "Issue a token", Tag: "auth", Request: &authRequest{}, Response: &auth.Token{}},
+	"GET /v1/auth/me":   {Summary: "Get the authenticated user and their permissions", Tag: "auth", Response: &auth.UserPermission{}},
+	"GET /openapi.json": {Summary: "Get this document", Tag: "docs", Response: map[string]interface{}{}},
+	"GET /docs":         {Summary: "Read this document", Tag: "docs", Response: "", ResponseType: "text/html"},
+	"GET /healthz":      {Summary: "Tell the process is alive", Tag: "health", Response: &health.Report{}},
+	"GET /readyz":       {Summary: "Check the dependencies, fails with 503 when one is not usable", Tag: "health", Response: &health.Report{}},
+	"GET /metrics":      {Summary: "Get the Prometheus metrics, requires the metrics token", Tag: "metrics", Response: "", ResponseType: metrics.ContentType},
+
+	"GET /v1/users":                     {Summary: "List users", Tag: "users", Query: pageParams(user.QuerySpec), Response: &userPage{}},
+	"GET /v1/users/{id}":                {Summary: "Get a user", Tag: "users", Response: &user.User{}},
+	"POST /v1/users":                    {Summary: "Create a user", Tag: "users", Request: &user.User{}, Status: http.StatusCreated},
+	"PUT /v1/users/{id}":                {Summary: "Update a user, If-Match must have the ETag", Tag: "users", Request: &user.User{}},
+	"PATCH /v1/users/{id}":              {Summary: "Patch a user", Tag: "users", Patch: &user.User{}, Response: &user.User{}},
+	"DELETE /v1/users/{id}":             {Summary: "Move a user to the trash", Tag: "users", Status: http.StatusNoContent},
+	"GET /v1/users/trash":               {Summary: "List the users in the trash", Tag: "users", Response: []*user.User{}},
+	"POST /v1/users/trash/{id}/restore": {Summary: "Restore a user from the trash", Tag: "users", Status: http.StatusNoContent},
+
+	"GET /v1/lists":                     {Summary: "List lists", Tag: "lists", Query: append(pageParams(list.ListQuerySpec), listParams...), Response: &listPage{}},
+	"GET /v1/lists/{id}":                {Summary: "Get a list", Tag: "lists", Query: listParams, Response: &list.List{}},
+	"POST /v1/lists":                    {Summary: "Create a list", Tag: "lists", Request: &list.List{}, Status: http.StatusCreated},
+	"PUT /v1/lists/{id}":                {Summary: "Update a list, If-Match must have the ETag", Tag: "lists", Request: &list.List{}},
+	"PATCH /v1/lists/{id}":              {Summary: "Patch a list", Tag: "lists", Patch: &list.List{}, Response: &list.List{}},
+	"DELETE /v1/lists/{id}":             {Summary: "Move a list to the trash", Tag: "lists", Status: http.StatusNoContent},
+	"GET /v1/lists/stats":               {Summary: "Get the stats of every list", Tag: "lists", Response: []*list.Stats{}},
+	"GET /v1/lists/{id}/stats":          {Summary: "Get the stats of a list", Tag: "lists", Response: &list.Stats{}},
+	"GET /v1/lists/trash":               {Summary: "List the lists in the trash", Tag: "lists", Response: []*list.List{}},
+	"POST /v1/lists/trash/{id}/restore": {Summary: "Restore a list from the trash", Tag: "lists", Status: http.StatusNoContent},
+	"GET /v1/lists/{id}/history":        {Summary: "List the revisions of a list and its items", Tag: "history", Response: []*list.Revision{}},
+	"POST /v1/lists/{id}/history/{revisionId}/restore": {
+		Summary: "Restore the state recorded by a revision", Tag: "history", Response: &list.Revision{}, Status: http.StatusCreated,
+	},
+	"GET /v1/lists/{id}/events": {
+		Summary: "Stream the changes of a list with Server-Sent Events or a WebSocket", Tag: "lists",
+		Query: []*openapi.Parameter{
+			openapi.QueryParam("last_event_id", "integer", "resume after this event, same as Last-Event-ID"),
+			openapi.QueryParam("access_token", "string", "the token, for browsers that can't set the Authorization header on EventSource and WebSocket"),
+		},
+		Response: &event.Event{}, ResponseType: "text/event-stream",
+	},
+
+	"GET /v1/lists/{id}/items":                         {Summary: "List the items of a list", Tag: "items", Query: append(pageParams(list.ItemQuerySpec), listParams[1]), Response: &listItemPage{}},
+	"GET /v1/lists/{id}/items/{itemId}":                {Summary: "Get an item", Tag: "items", Response: &list.ListItem{}},
+	"POST /v1/lists/{id}/items":                        {Summary: "Create an item", Tag: "items", Request: &list.ListItem{}, Status: http.StatusCreated},
+	"PUT /v1/lists/{id}/items/{itemId}":                {Summary: "Update an item, If-Match must have the ETag", Tag: "items", Request: &list.ListItem{}},
+	"PATCH /v1/lists/{id}/items/{itemId}":              {Summary: "Patch an item", Tag: "items", Patch: &list.ListItem{}, Response: &list.ListItem{}},
+	"DELETE /v1/lists/{id}/items/{itemId}":             {Summary: "Move an item to the trash", Tag: "items", Status: http.StatusNoContent},
+	"POST /v1/lists/{id}/items:batch":                  {Summary: "Create, update and delete items at once", Tag: "items", Request: &batchRequest{}, Response: &batchResponse{}},
+	"PUT /v1/lists/{id}/items/{itemId}/tags":           {Summary: "Replace the tags of an item", Tag: "items", Request: &tagsRequest{}},
+	"GET /v1/lists/{id}/items/trash":                   {Summary: "List the items of a list in the trash", Tag: "items", Response: []*list.ListItem{}},
+	"POST /v1/lists/{id}/items/trash/{itemId}/restore": {Summary: "Restore an item from the trash", Tag: "items", Status: http.StatusNoContent},
+	"GET /v1/lists/{id}/items/{itemId}/reminders":      {Summary: "List the reminders sent for an item", Tag: "items", Response: []*reminder.Delivery{}},
+	"GET /v1/tags": {
+		Summary: "List the tags of the user", Tag: "items",
+		Query:    []*openapi.Parameter{openapi.QueryParam("q", "string", "prefix of the tags")},
+		Response: []*list.Tag{},
+	},
+	"GET /v1/items": {
+		Summary: "List the items with some or all of the tags", Tag: "items",
+		Query: []*openapi.Parameter{
+			{Name: "tags", In: "query", Required: true, Description: "comma separated tags", Schema: &openapi.Schema{Type: "string"}},
+			openapi.QueryParam("match", "string", "any (default) or all"),
+		},
+		Response: []*list.ListItem{},
+	},
+
+	"GET /v1/recurrences":           {Summary: "List recurrences", Tag: "recurrences", Response: []*recurrence.Recurrence{}},
+	"GET /v1/recurrences/{id}":      {Summary: "Get a recurrence", Tag: "recurrences", Response: &recurrence.Recurrence{}},
+	"POST /v1/recurrences":          {Summary: "Create a recurrence", Tag: "recurrences", Request: &recurrence.Recurrence{}, Response: &recurrence.Recurrence{}, Status: http.StatusCreated},
+	"PUT /v1/recurrences/{id}":      {Summary: "Update a recurrence", Tag: "recurrences", Request: &recurrence.Recurrence{}},
+	"DELETE /v1/recurrences/{id}":   {Summary: "Remove a recurrence", Tag: "recurrences", Status: http.StatusNoContent},
+	"GET /v1/recurrences/{id}/runs": {Summary: "List the lists generated by a recurrence", Tag: "recurrences", Response: []*recurrence.Run{}},
+
+	"GET /v1/sync": {
+		Summary: "Get what changed since a cursor", Tag: "sync",
+		Query:    []*openapi.Parameter{openapi.QueryParam("since", "string", "cursor of the previous sync, empty to get everything")},
+		Response: &list.Changes{},
+	},
+	"POST /v1/sync": {Summary: "Apply the changes made offline", Tag: "sync", Request: &syncRequest{}, Response: &syncResponse{}},
+
+	"GET /v1/search": {
+		Summary: "Search the lists and items the caller is allowed to read", Tag: "search",
+		Query: []*openapi.Parameter{
+			{Name: "q", In: "query", Required: true, Schema: &openapi.Schema{Type: "string"}},
+			openapi.QueryParam("limit", "integer", "at most 100 results"),
+		},
+		Response: &searchResponse{},
+	},
+}
+
+// MakeDocsHandlers serves the OpenAPI document of the routes of r and a page
+// to read it, it must be called after every other route is registered
+func MakeDocsHandlers(r *mux.Router, n *negroni.Negroni) {
+	r.Handle("/openapi.json", n.With(
+		negroni.Wrap(getOpenAPI(r)),
+	)).Methods("GET", "OPTIONS")
+
+	r.Handle("/docs", n.With(
+		negroni.Wrap(getDocs()),
+	)).Methods("GET", "OPTIONS")
+}
+
+func getOpenAPI(r *mux.Router) http.Handler {
+	var once sync.Once
+	var doc *openapi.Document
+	var err error
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		once.Do(func() {
+			// routes without documentation are still listed in the document
+			doc, err = openapi.Build(apiInfo, r, apiRoutes)
+			if _, ok := err.(openapi.Undocumented); ok {
+				err = nil
+			}
+		})
+		if err != nil {
+			common.WriteProblem(w, req, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		json.NewEncoder(w).Encode(doc)
+	})
+}
+
+func getDocs() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(docsPage)
+	})
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cristiano-pacheco/go-api/core/auth"
+	"github.com/cristiano-pacheco/go-api/core/health"
+	"github.com/cristiano-pacheco/go-api/core/list"
+	"github.com/cristiano-pacheco/go-api/core/metrics"
+	"github.com/cristiano-pacheco/go-api/core/recurrence"
+	"github.com/cristiano-pacheco/go-api/core/reminder"
+	"github.com/cristiano-pacheco/go-api/core/user"
+	"github.com/cristiano-pacheco/go-api/web/openapi"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/negroni"
+)
+
+// newTestRouter registers the routes with the function used by web/main.go
+func newTestRouter() *mux.Router {
+	r := mux.NewRouter()
+	MakeHandlers(r, negroni.New(), &Dependencies{
+		Auth:         &auth.Service{},
+		User:         &user.Service{},
+		List:         &list.Service{},
+		Recurrence:   &recurrence.Service{},
+		Reminder:     &reminder.Service{},
+		Checker:      health.NewChecker(time.Second),
+		Metrics:      metrics.NewRegistry(nil),
+		MetricsToken: "metrics-token",
+	})
+
+	return r
+}
+
+func TestOpenAPI(t *testing.T) {
+	doc, err := openapi.Build(apiInfo, newTestRouter(), apiRoutes)
+
+	t.Run("Teste todas as rotas documentadas", func(t *testing.T) {
+		assert.Nil(t, err, "add the routes to apiRoutes in web/handler/openapi.go")
+	})
+
+	t.Run("Teste rotas documentadas existem", func(t *testing.T) {
+		for key := range apiRoutes {
+			parts := strings.SplitN(key, " ", 2)
+			_, ok := doc.Paths[parts[1]][strings.ToLower(parts[0])]
+			assert.True(t, ok, "%s is documented but not registered", key)
+		}
+	})
+
+	t.Run("Teste permissões e esquemas", func(t *testing.T) {
+		op := doc.Paths["/v1/lists/{id}"]["put"]
+		assert.Equal(t, auth.UpdateListAction, op.Permission)
+		assert.NotEmpty(t, op.Security)
+		assert.Equal(t, "id", op.Parameters[0].Name)
+		assert.Empty(t, doc.Paths["/v1/auth"]["post"].Security)
+
+		raw, err := json.Marshal(doc.Components.Schemas["List"])
+		assert.Nil(t, err)
+		assert.JSONEq(t, `{
+			"type": "object",
+			"required": ["name"],
+			"properties": {
+				"id": {"type": "integer", "format": "int64"},
+				"name": {"type": "string", "maxLength": 255},
+				"notes": {"type": "string", "maxLength": 2000},
+				"is_active": {"type": "boolean"},
+				"version": {"type": "integer", "format": "int64"},
+				"created_at": {"type": "string", "format": "date-time"},
+				"updated_at": {"type": "string", "format": "date-time"},
+				"deleted_at": {"type": "string", "format": "date-time"},
+				"items": {"type": "array", "items": {"$ref": "#/components/schemas/ListItem"}}
+			}
+		}`, string(raw))
+	})
+}
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/cristiano-pacheco/go-api/core/patch"
+	"github.com/cristiano-pacheco/go-api/web/common"
+)
+
+// maxPatchSize limits the size of a PATCH request body
+const maxPatchSize = 1 << 20
+
+// patchVersion returns the version a PATCH is based on: the If-Match header
+// when it is sent, otherwise the version of the record the patch was applied to
+func patchVersion(w http.ResponseWriter, r *http.Request, current int64) (int64, bool) {
+	if r.Header.Get("If-Match") == "" {
+		return current, true
+	}
+
+	return ifMatchVersion(w, r)
+}
+
+// applyPatch applies the merge patch or JSON patch of the request body to the
+// JSON of current and decodes the result into patched. When the patch cannot
+// be applied the error response is written and false returned.
+func applyPatch(w http.ResponseWriter, r *http.Request, current interface{}, patched interface{}) bool {
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxPatchSize))
+	if err != nil {
+		common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+		return false
+	}
+
+	doc, err := json.Marshal(current)
+	if err != nil {
+		common.WriteProblem(w, r, http.StatusInternalServerError, err.Error())
+		return false
+	}
+
+	result, err := patch.Apply(r.Header.Get("Content-Type"), doc, body)
+	if err != nil {
+		status := http.StatusUnprocessableEntity
+		switch err {
+		case patch.ErrUnsupportedType:
+			status = http.StatusUnsupportedMediaType
+		case patch.ErrTestFailed:
+			status = http.StatusConflict
+		}
+		common.WriteProblem(w, r, status, err.Error())
+		return false
+	}
+
+	err = json.Unmarshal(result, patched)
+	if err != nil {
+		common.WriteProblem(w, r, http.StatusUnprocessableEntity, err.Error())
+		return false
+	}
+
+	return true
+}
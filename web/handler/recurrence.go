@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/cristiano-pacheco/go-api/core/auth"
+	"github.com/cristiano-pacheco/go-api/core/recurrence"
+	"github.com/cristiano-pacheco/go-api/web/common"
+	"github.com/cristiano-pacheco/go-api/web/middleware"
+	"github.com/gorilla/mux"
+	"github.com/urfave/negroni"
+)
+
+// MakeRecurrenceHandlers create all resource handlers
+func MakeRecurrenceHandlers(r *mux.Router, n *negroni.Negroni, service recurrence.UseCase, authService *auth.Service) {
+	r.Handle("/v1/recurrences", n.With(
+		middleware.CheckAuthentication(authService),
+		negroni.Wrap(getAllRecurrences(service)),
+	)).Methods("GET", "OPTIONS").Name(auth.GetAllRecurrencesAction)
+
+	r.Handle("/v1/recurrences/{id}", n.With(
+		middleware.CheckAuthentication(authService),
+		negroni.Wrap(getRecurrence(service)),
+	)).Methods("GET", "OPTIONS").Name(auth.GetRecurrenceAction)
+
+	r.Handle("/v1/recurrences", n.With(
+		middleware.CheckAuthentication(authService),
+		negroni.Wrap(storeRecurrence(service)),
+	)).Methods("POST", "OPTIONS").Name(auth.StoreRecurrenceAction)
+
+	r.Handle("/v1/recurrences/{id}", n.With(
+		middleware.CheckAuthentication(authService),
+		negroni.Wrap(updateRecurrence(service)),
+	)).Methods("PUT", "OPTIONS").Name(auth.UpdateRecurrenceAction)
+
+	r.Handle("/v1/recurrences/{id}", n.With(
+		middleware.CheckAuthentication(authService),
+		negroni.Wrap(removeRecurrence(service)),
+	)).Methods("DELETE", "OPTIONS").Name(auth.RemoveRecurrenceAction)
+
+	r.Handle("/v1/recurrences/{id}/runs", n.With(
+		middleware.CheckAuthentication(authService),
+		negroni.Wrap(getAllRecurrenceRuns(service)),
+	)).Methods("GET", "OPTIONS").Name(auth.GetAllRecurrenceRunsAction)
+}
+
+func getAllRecurrences(service recurrence.UseCase) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		all, err := service.GetAll()
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		err = json.NewEncoder(w).Encode(all)
+		if err != nil {
+			common.WriteProblem(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+	})
+}
+
+func getRecurrence(service recurrence.UseCase) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := strconv.ParseInt(vars["id"], 10, 64)
+		if err != nil {
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		rec, err := service.Get(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		err = json.NewEncoder(w).Encode(rec)
+		if err != nil {
+			common.WriteProblem(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+	})
+}
+
+func storeRecurrence(service recurrence.UseCase) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// an omitted is_active keeps the recurrence running
+		rec := recurrence.Recurrence{IsActive: true}
+
+		err := json.NewDecoder(r.Body).Decode(&rec)
+		if err != nil {
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		err = service.Store(&rec)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(rec)
+	})
+}
+
+func updateRecurrence(service recurrence.UseCase) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := strconv.ParseInt(vars["id"], 10, 64)
+		if err != nil {
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		rec := recurrence.Recurrence{IsActive: true}
+
+		err = json.NewDecoder(r.Body).Decode(&rec)
+		if err != nil {
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		rec.ID = id
+		err = service.Update(&rec)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func removeRecurrence(service recurrence.UseCase) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := strconv.ParseInt(vars["id"], 10, 64)
+		if err != nil {
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		err = service.Remove(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func getAllRecurrenceRuns(service recurrence.UseCase) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := strconv.ParseInt(vars["id"], 10, 64)
+		if err != nil {
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		all, err := service.GetAllRuns(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		err = json.NewEncoder(w).Encode(all)
+		if err != nil {
+			common.WriteProblem(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+	})
+}
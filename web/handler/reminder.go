@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/cristiano-pacheco/go-api/core/auth"
+	"github.com/cristiano-pacheco/go-api/core/reminder"
+	"github.com/cristiano-pacheco/go-api/web/common"
+	"github.com/cristiano-pacheco/go-api/web/middleware"
+	"github.com/gorilla/mux"
+	"github.com/urfave/negroni"
+)
+
+// MakeReminderHandlers create all resource handlers
+func MakeReminderHandlers(r *mux.Router, n *negroni.Negroni, service reminder.UseCase, authService *auth.Service) {
+	r.Handle("/v1/lists/{id}/items/{itemId}/reminders", n.With(
+		middleware.CheckAuthentication(authService),
+		negroni.Wrap(getAllReminderDeliveries(service)),
+	)).Methods("GET", "OPTIONS").Name(auth.GetAllReminderDeliveriesAction)
+}
+
+func getAllReminderDeliveries(service reminder.UseCase) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		itemId, err := strconv.ParseInt(vars["itemId"], 10, 64)
+		if err != nil {
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		all, err := service.GetAllDeliveries(itemId)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		err = json.NewEncoder(w).Encode(all)
+		if err != nil {
+			common.WriteProblem(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+	})
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"github.com/cristiano-pacheco/go-api/core/auth"
+	"github.com/cristiano-pacheco/go-api/core/event"
+	"github.com/cristiano-pacheco/go-api/core/health"
+	"github.com/cristiano-pacheco/go-api/core/list"
+	"github.com/cristiano-pacheco/go-api/core/recurrence"
+	"github.com/cristiano-pacheco/go-api/core/reminder"
+	"github.com/cristiano-pacheco/go-api/core/search"
+	"github.com/cristiano-pacheco/go-api/core/user"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/urfave/negroni"
+)
+
+// Dependencies are the services used by the routes of the API
+type Dependencies struct {
+	Auth       *auth.Service
+	User       user.UseCase
+	List       list.UseCase
+	Recurrence recurrence.UseCase
+	Reminder   reminder.UseCase
+	Broker     *event.Broker
+	Search     search.Index
+	Checker    *health.Checker
+	// Metrics is served on the API listener only when MetricsToken is set
+	Metrics      *prometheus.Registry
+	MetricsToken string
+}
+
+// MakeHandlers registers every route of the API, the documentation last so
+// it describes all of them
+func MakeHandlers(r *mux.Router, n *negroni.Negroni, d *Dependencies) {
+	MakeAuthHandlers(r, n, d.Auth)
+	MakeUserHandlers(r, n, d.User, d.Auth)
+	MakeListHandlers(r, n, d.List, d.Auth)
+	MakeSyncHandlers(r, n, d.List, d.Auth)
+	MakeRecurrenceHandlers(r, n, d.Recurrence, d.Auth)
+	MakeReminderHandlers(r, n, d.Reminder, d.Auth)
+	MakeEventHandlers(r, n, d.Broker, d.List, d.Auth)
+	MakeSearchHandlers(r, n, d.Search, d.Auth)
+	MakeHealthHandlers(r, n, d.Checker)
+	if d.MetricsToken != "" {
+		MakeMetricsHandlers(r, n, d.Metrics, d.MetricsToken)
+	}
+	MakeDocsHandlers(r, n)
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/cristiano-pacheco/go-api/core/auth"
+	"github.com/cristiano-pacheco/go-api/core/search"
+	"github.com/cristiano-pacheco/go-api/web/common"
+	"github.com/cristiano-pacheco/go-api/web/middleware"
+	"github.com/gorilla/mux"
+	"github.com/urfave/negroni"
+)
+
+// maxSearchLimit limits how many results are returned by one search
+const maxSearchLimit = 100
+
+type searchResponse struct {
+	Data []*search.Result `json:"data"`
+}
+
+// readActions are the permissions that allow reading each document type
+var readActions = map[string][]string{
+	search.TypeList:     {auth.GetListAction, auth.GetAllListsAction},
+	search.TypeListItem: {auth.GetListItemAction, auth.GetAllListItemsAction},
+}
+
+// MakeSearchHandlers create the handler of the full-text search
+func MakeSearchHandlers(r *mux.Router, n *negroni.Negroni, index search.Index, authService *auth.Service) {
+	r.Handle("/v1/search", n.With(
+		middleware.CheckAuthentication(authService),
+		negroni.Wrap(searchAll(index, authService)),
+	)).Methods("GET", "OPTIONS").Name(auth.SearchAction)
+}
+
+// readableTypes returns the document types the user is allowed to read
+func readableTypes(authService *auth.Service, userID int64) ([]string, error) {
+	var types []string
+	for _, typ := range []string{search.TypeList, search.TypeListItem} {
+		for _, action := range readActions[typ] {
+			ok, err := authService.HasAccess(int(userID), action)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				types = append(types, typ)
+				break
+			}
+		}
+	}
+
+	return types, nil
+}
+
+func searchAll(index search.Index, authService *auth.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := strings.TrimSpace(r.URL.Query().Get("q"))
+		if q == "" {
+			common.WriteProblem(w, r, http.StatusBadRequest, "q is required")
+			return
+		}
+
+		limit := search.DefaultLimit
+		if v := r.URL.Query().Get("limit"); v != "" {
+			var err error
+			limit, err = strconv.Atoi(v)
+			if err != nil || limit < 1 || limit > maxSearchLimit {
+				common.WriteProblem(w, r, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(maxSearchLimit))
+				return
+			}
+		}
+
+		userID, _ := auth.UserIDFromContext(r.Context())
+		types, err := readableTypes(authService, userID)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		results, err := index.Search(q, types, limit)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		err = json.NewEncoder(w).Encode(&searchResponse{Data: results})
+		if err != nil {
+			common.WriteProblem(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+	})
+}
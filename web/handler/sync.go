@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/cristiano-pacheco/go-api/core/auth"
+	"github.com/cristiano-pacheco/go-api/core/list"
+	"github.com/cristiano-pacheco/go-api/web/common"
+	"github.com/cristiano-pacheco/go-api/web/middleware"
+	"github.com/gorilla/mux"
+	"github.com/urfave/negroni"
+)
+
+// maxSyncMutations limits how many mutations a client pushes in one request
+const maxSyncMutations = 500
+
+type syncRequest struct {
+	Mutations []*list.Mutation `json:"mutations"`
+}
+
+type syncResponse struct {
+	Results []*list.MutationResult `json:"results"`
+}
+
+// MakeSyncHandlers create the handlers used by offline clients to sync lists
+func MakeSyncHandlers(r *mux.Router, n *negroni.Negroni, service list.UseCase, authService *auth.Service) {
+	r.Handle("/v1/sync", n.With(
+		middleware.CheckAuthentication(authService),
+		negroni.Wrap(getSyncChanges(service)),
+	)).Methods("GET", "OPTIONS").Name(auth.GetSyncChangesAction)
+
+	r.Handle("/v1/sync", n.With(
+		middleware.CheckAuthentication(authService),
+		negroni.Wrap(storeSyncMutations(service)),
+	)).Methods("POST", "OPTIONS").Name(auth.StoreSyncMutationsAction)
+}
+
+func getSyncChanges(service list.UseCase) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		changes, err := service.GetChanges(r.URL.Query().Get("since"))
+		if err == list.ErrCursorExpired {
+			common.WriteProblem(w, r, http.StatusGone, err.Error())
+			return
+		}
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		err = json.NewEncoder(w).Encode(changes)
+		if err != nil {
+			common.WriteProblem(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+	})
+}
+
+func storeSyncMutations(service list.UseCase) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req syncRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if len(req.Mutations) > maxSyncMutations {
+			common.WriteProblem(w, r, http.StatusRequestEntityTooLarge, "too many mutations in one request")
+			return
+		}
+
+		results := service.WithContext(r.Context()).ApplyMutations(req.Mutations)
+
+		err = json.NewEncoder(w).Encode(&syncResponse{Results: results})
+		if err != nil {
+			common.WriteProblem(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+	})
+}
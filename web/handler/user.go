@@ -6,6 +6,7 @@ import (
 	"strconv"
 
 	"github.com/cristiano-pacheco/go-api/core/auth"
+	"github.com/cristiano-pacheco/go-api/core/query"
 	"github.com/cristiano-pacheco/go-api/core/user"
 	"github.com/cristiano-pacheco/go-api/web/common"
 	"github.com/cristiano-pacheco/go-api/web/middleware"
@@ -15,6 +16,17 @@ import (
 
 // MakeUserHandlers create all user resource handlers
 func MakeUserHandlers(r *mux.Router, n *negroni.Negroni, service user.UseCase, authService *auth.Service) {
+	// registered before /v1/users/{id} so "trash" is not taken as an id
+	r.Handle("/v1/users/trash", n.With(
+		middleware.CheckAuthentication(authService),
+		negroni.Wrap(getUserTrash(service)),
+	)).Methods("GET", "OPTIONS").Name(auth.GetUserTrashAction)
+
+	r.Handle("/v1/users/trash/{id}/restore", n.With(
+		middleware.CheckAuthentication(authService),
+		negroni.Wrap(restoreUser(service)),
+	)).Methods("POST", "OPTIONS").Name(auth.RestoreUserAction)
+
 	r.Handle("/v1/users", n.With(
 		middleware.CheckAuthentication(authService),
 		negroni.Wrap(getAllUsers(service)),
@@ -35,6 +47,11 @@ func MakeUserHandlers(r *mux.Router, n *negroni.Negroni, service user.UseCase, a
 		negroni.Wrap(updateUser(service)),
 	)).Methods("PUT", "OPTIONS").Name(auth.UpdateUserAction)
 
+	r.Handle("/v1/users/{id}", n.With(
+		middleware.CheckAuthentication(authService),
+		negroni.Wrap(patchUser(service)),
+	)).Methods("PATCH", "OPTIONS").Name(auth.PatchUserAction)
+
 	r.Handle("/v1/users/{id}", n.With(
 		middleware.CheckAuthentication(authService),
 		negroni.Wrap(removeUser(service)),
@@ -43,17 +60,21 @@ func MakeUserHandlers(r *mux.Router, n *negroni.Negroni, service user.UseCase, a
 
 func getAllUsers(service user.UseCase) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		all, err := service.GetAll()
+		p, err := query.Parse(r.URL.Query(), user.QuerySpec)
 		if err != nil {
-			w.Write(common.FormatJSONError(err.Error()))
-			w.WriteHeader(http.StatusInternalServerError)
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
 			return
 		}
 
-		err = json.NewEncoder(w).Encode(all)
+		all, res, err := service.GetAll(p)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		err = json.NewEncoder(w).Encode(query.NewPage(all, res))
 		if err != nil {
-			w.Write(common.FormatJSONError(err.Error()))
-			w.WriteHeader(http.StatusInternalServerError)
+			common.WriteProblem(w, r, http.StatusInternalServerError, err.Error())
 			return
 		}
 	})
@@ -65,22 +86,20 @@ func getUser(service user.UseCase) http.Handler {
 
 		id, err := strconv.ParseInt(vars["id"], 10, 64)
 		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			w.Write(common.FormatJSONError(err.Error()))
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
 			return
 		}
 
 		u, err := service.Get(id)
 		if err != nil {
-			w.Write(common.FormatJSONError(err.Error()))
-			w.WriteHeader(http.StatusInternalServerError)
+			writeError(w, r, err)
 			return
 		}
 
+		w.Header().Set("ETag", common.ETag(u.Version))
 		err = json.NewEncoder(w).Encode(u)
 		if err != nil {
-			w.Write(common.FormatJSONError(err.Error()))
-			w.WriteHeader(http.StatusInternalServerError)
+			common.WriteProblem(w, r, http.StatusInternalServerError, err.Error())
 			return
 		}
 	})
@@ -92,15 +111,13 @@ func storeUser(service user.UseCase) http.Handler {
 
 		err := json.NewDecoder(r.Body).Decode(&u)
 		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			w.Write(common.FormatJSONError(err.Error()))
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
 			return
 		}
 
 		err = service.Store(&u)
 		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			w.Write(common.FormatJSONError(err.Error()))
+			writeError(w, r, err)
 			return
 		}
 		w.WriteHeader(http.StatusCreated)
@@ -113,8 +130,12 @@ func updateUser(service user.UseCase) http.Handler {
 
 		id, err := strconv.ParseInt(vars["id"], 10, 64)
 		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			w.Write(common.FormatJSONError(err.Error()))
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		version, ok := ifMatchVersion(w, r)
+		if !ok {
 			return
 		}
 
@@ -122,19 +143,19 @@ func updateUser(service user.UseCase) http.Handler {
 
 		err = json.NewDecoder(r.Body).Decode(&u)
 		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			w.Write(common.FormatJSONError(err.Error()))
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
 			return
 		}
 
 		u.ID = id
+		u.Version = version
 		err = service.Update(&u)
 		if err != nil {
-			w.Write(common.FormatJSONError(err.Error()))
-			w.WriteHeader(http.StatusInternalServerError)
+			writeUpdateError(w, r, err)
 			return
 		}
 
+		w.Header().Set("ETag", common.ETag(u.Version))
 		w.WriteHeader(http.StatusOK)
 	})
 }
@@ -145,18 +166,92 @@ func removeUser(service user.UseCase) http.Handler {
 
 		id, err := strconv.ParseInt(vars["id"], 10, 64)
 		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			w.Write(common.FormatJSONError(err.Error()))
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
 			return
 		}
 
 		err = service.Remove(id)
 		if err != nil {
-			w.Write(common.FormatJSONError(err.Error()))
-			w.WriteHeader(http.StatusInternalServerError)
+			writeError(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func getUserTrash(service user.UseCase) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		all, err := service.GetTrash()
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		err = json.NewEncoder(w).Encode(all)
+		if err != nil {
+			common.WriteProblem(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+	})
+}
+
+func restoreUser(service user.UseCase) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := strconv.ParseInt(vars["id"], 10, 64)
+		if err != nil {
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		err = service.RestoreFromTrash(id)
+		if err != nil {
+			writeError(w, r, err)
 			return
 		}
 
 		w.WriteHeader(http.StatusNoContent)
 	})
 }
+
+func patchUser(service user.UseCase) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := strconv.ParseInt(vars["id"], 10, 64)
+		if err != nil {
+			common.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		current, err := service.Get(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		version, ok := patchVersion(w, r, current.Version)
+		if !ok {
+			return
+		}
+
+		var u user.User
+		if !applyPatch(w, r, current, &u) {
+			return
+		}
+
+		u.ID = id
+		u.Version = version
+		err = service.Update(&u)
+		if err != nil {
+			writeUpdateError(w, r, err)
+			return
+		}
+
+		w.Header().Set("ETag", common.ETag(u.Version))
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(&u)
+	})
+}
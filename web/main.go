@@ -1,16 +1,28 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"flag"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/cristiano-pacheco/go-api/core/auth"
+	"github.com/cristiano-pacheco/go-api/core/event"
+	"github.com/cristiano-pacheco/go-api/core/health"
 	"github.com/cristiano-pacheco/go-api/core/list"
+	"github.com/cristiano-pacheco/go-api/core/logger"
+	"github.com/cristiano-pacheco/go-api/core/metrics"
+	"github.com/cristiano-pacheco/go-api/core/recurrence"
+	"github.com/cristiano-pacheco/go-api/core/reminder"
+	"github.com/cristiano-pacheco/go-api/core/search"
+	"github.com/cristiano-pacheco/go-api/core/trash"
 	"github.com/cristiano-pacheco/go-api/core/user"
+	"github.com/cristiano-pacheco/go-api/core/worker"
 	"github.com/cristiano-pacheco/go-api/web/handler"
 	"github.com/cristiano-pacheco/go-api/web/middleware"
 	"github.com/gbrlsnchs/jwt/v3"
@@ -18,6 +30,8 @@ import (
 
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/urfave/negroni"
 )
 
@@ -25,27 +39,129 @@ func main() {
 	dsn := flag.String("dsn", "root:root@/go_api?parseTime=true", "MySQL data source name")
 	addr := flag.String("addr", ":4000", "HTTP network address")
 	jwtkey := flag.String("jwtkey", "jwt-private-key", "JWT Private Key")
+	schedulerInterval := flag.Duration("scheduler-interval", time.Minute, "Interval between recurring list generation runs")
+	reminderInterval := flag.Duration("reminder-interval", time.Minute, "Interval between reminder dispatches")
+	reminderNotifier := flag.String("reminder-notifier", "log", "Reminder notifier: log, outbox or webhook")
+	reminderEmail := flag.String("reminder-email", "", "Single recipient of every reminder email when using the outbox notifier, lists have no owner")
+	reminderWebhook := flag.String("reminder-webhook", "", "URL the reminders are posted to when using the webhook notifier")
+	trashRetention := flag.Duration("trash-retention", 30*24*time.Hour, "How long removed users, lists and items are kept in the trash")
+	purgeInterval := flag.Duration("purge-interval", time.Hour, "Interval between trash purges")
+	searchEngine := flag.String("search-engine", "mysql", "Search engine: mysql or memory")
+	metricsAddr := flag.String("metrics-addr", "", "Network address of a separate listener for /metrics")
+	metricsToken := flag.String("metrics-token", "", "Bearer token required by /metrics on the main listener, empty disables it there")
+	shutdownTimeout := flag.Duration("shutdown-timeout", 30*time.Second, "How long in-flight requests have to finish on shutdown")
+	shutdownDelay := flag.Duration("shutdown-delay", 0, "How long /readyz fails before the listeners close, should cover the readiness probe period")
+	logLevel := flag.String("log-level", "info", "Minimum level of the logs: debug, info, warn or error")
 	flag.Parse()
 
-	db, err := sql.Open("mysql", *dsn)
+	level, err := logger.ParseLevel(*logLevel)
 	if err != nil {
 		log.Fatal(err)
 	}
-	defer db.Close()
+	logger.SetDefault(logger.New(os.Stdout, level))
+
+	// the context is canceled on the first signal, a second one kills the process
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	db, err := sql.Open("mysql", *dsn)
+	if err != nil {
+		fatal("cannot open the database", "error", err)
+	}
+
+	// the API still starts without the database, /readyz fails until it is back
+	if err := db.Ping(); err != nil {
+		logger.Default().Warn("database is unreachable", "error", err)
+	}
 
 	// Hash used to sign and verify the JWT tokens
 	jwtHash := jwt.NewHS256([]byte(*jwtkey))
 
+	// Broker of the list change events
+	broker := event.NewBroker(1000)
+
+	// Metrics of the requests, the connection pool and the authentication
+	registry := metrics.NewRegistry(db)
+
 	// Services creation
 	authService := auth.NewService(db, &auth.Validator{}, jwtHash)
+	authService.Failures = promauto.With(registry).NewCounterVec(prometheus.CounterOpts{
+		Name: "goapi_auth_failures_total",
+		Help: "Number of failed authentications by reason.",
+	}, []string{"reason"})
+	authService.PermissionChecks = promauto.With(registry).NewCounterVec(prometheus.CounterOpts{
+		Name: "goapi_permission_checks_total",
+		Help: "Number of permission checks by action and result.",
+	}, []string{"action", "result"})
 	userService := user.NewService(db, &user.Validator{})
 	listService := list.NewService(db, &list.Validator{})
+	listService.Events = broker
+	listService.TrashRetention = *trashRetention
+	recurrenceService := recurrence.NewService(db, &recurrence.Validator{})
+
+	var notifier reminder.Notifier
+	switch *reminderNotifier {
+	case "log":
+		notifier = &reminder.LogNotifier{Logger: logger.Default()}
+	case "outbox":
+		if *reminderEmail == "" {
+			fatal("the outbox reminder notifier requires -reminder-email")
+		}
+		notifier = &reminder.OutboxNotifier{DB: db, To: *reminderEmail}
+	case "webhook":
+		if *reminderWebhook == "" {
+			fatal("the webhook reminder notifier requires -reminder-webhook")
+		}
+		notifier = &reminder.WebhookNotifier{URL: *reminderWebhook, Client: &http.Client{Timeout: 10 * time.Second}}
+	default:
+		fatal("unknown reminder notifier", "notifier", *reminderNotifier)
+	}
+	reminderService := reminder.NewService(db, notifier)
+
+	var searchIndex search.Index
+	switch *searchEngine {
+	case "mysql":
+		searchIndex = search.NewMySQLIndex(db)
+	case "memory":
+		memoryIndex := search.NewMemoryIndex()
+		go search.Follow(memoryIndex, broker, listService)
+		searchIndex = memoryIndex
+	default:
+		fatal("unknown search engine", "engine", *searchEngine)
+	}
+
+	// Background workers
+	scheduler := worker.New("recurrence-scheduler", *schedulerInterval, func(now time.Time) error {
+		_, err := recurrenceService.RunDue(now)
+		return err
+	})
+	scheduler.Start()
+
+	dispatcher := worker.New("reminder-dispatcher", *reminderInterval, func(now time.Time) error {
+		_, err := reminderService.Dispatch(now)
+		return err
+	})
+	dispatcher.Start()
+
+	purger := worker.New("trash-purger", *purgeInterval, trash.PurgeJob(*trashRetention, userService, listService))
+	purger.Start()
+
+	// Readiness checks, a worker that missed three runs or whose run takes
+	// longer than three intervals is considered stalled
+	checker := health.NewChecker(2 * time.Second)
+	checker.Add("database", health.Ping(db))
+	checker.Add("schema_version", health.Schema(db, health.SchemaVersion))
+	checker.Add(scheduler.Name, health.Running(scheduler, 3**schedulerInterval))
+	checker.Add(dispatcher.Name, health.Running(dispatcher, 3**reminderInterval))
+	checker.Add(purger.Name, health.Running(purger, 3**purgeInterval))
 
 	// Router, Middlewares and Handlers
 	r := mux.NewRouter()
 
 	n := negroni.New(
-		negroni.NewLogger(),
+		middleware.RequestID(),
+		middleware.Logger(logger.Default()),
+		middleware.Metrics(registry),
 	)
 
 	c := cors.New(cors.Options{
@@ -58,33 +174,125 @@ func main() {
 			http.MethodPatch,
 			http.MethodDelete,
 		},
-		AllowedHeaders:   []string{"Authorization", "Content-Type"},
+		AllowedHeaders:   []string{"Authorization", "Content-Type", "If-Match", "X-Request-ID"},
+		ExposedHeaders:   []string{"ETag", "X-Request-ID"},
 		AllowCredentials: false,
-		// Enable Debugging for testing, consider disabling in production
-		Debug: true,
 	})
+	// the CORS decisions are only logged at the debug level
+	if level == logger.Debug {
+		c.Log = logger.Default().With("component", "cors")
+	}
 
 	n.Use(c)
 	n.Use(middleware.SetJSONContentType())
+	n.Use(middleware.Localize())
 
-	// handlers
-	handler.MakeAuthHandlers(r, n, authService)
-	handler.MakeUserHandlers(r, n, userService, authService)
-	handler.MakeListHandlers(r, n, listService, authService)
+	// handlers, /metrics is on the API listener when it has no listener of its own
+	deps := &handler.Dependencies{
+		Auth:       authService,
+		User:       userService,
+		List:       listService,
+		Recurrence: recurrenceService,
+		Reminder:   reminderService,
+		Broker:     broker,
+		Search:     searchIndex,
+		Checker:    checker,
+		Metrics:    registry,
+	}
+	if *metricsAddr == "" {
+		deps.MetricsToken = *metricsToken
+	}
+	handler.MakeHandlers(r, n, deps)
 
 	http.Handle("/", r)
 
+	// errors of the listeners, they start the shutdown as well
+	serverErr := make(chan error, 2)
+	serve := func(srv *http.Server) {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			serverErr <- err
+		}
+	}
+
+	// the metrics listener is meant to be reachable only by the scrapers, the
+	// token is still checked when one is given
+	var metricsSrv *http.Server
+	if *metricsAddr != "" {
+		mr := mux.NewRouter()
+		handler.MakeMetricsHandlers(mr, negroni.New(), registry, *metricsToken)
+
+		metricsSrv = &http.Server{
+			ReadTimeout:  30 * time.Second,
+			WriteTimeout: 30 * time.Second,
+			Addr:         *metricsAddr,
+			Handler:      mr,
+			ErrorLog:     logger.Default().With("server", "metrics").StdLogger(logger.Error),
+		}
+
+		logger.Default().Info("metrics server started", "addr", *metricsAddr)
+		go serve(metricsSrv)
+	}
+
 	srv := &http.Server{
 		ReadTimeout:  30 * time.Second,
 		WriteTimeout: 30 * time.Second,
 		Addr:         *addr,
 		Handler:      http.DefaultServeMux,
-		ErrorLog:     log.New(os.Stderr, "logger: ", log.Lshortfile),
+		ErrorLog:     logger.Default().With("server", "api").StdLogger(logger.Error),
 	}
 
-	srv.ErrorLog.Printf("Server started at %s", *addr)
-	err = srv.ListenAndServe()
-	if err != nil {
-		log.Fatal(err)
+	logger.Default().Info("server started", "addr", *addr)
+	go serve(srv)
+
+	exitCode := 0
+	select {
+	case <-ctx.Done():
+		logger.Default().Info("shutting down", "timeout", shutdownTimeout.String())
+	case err := <-serverErr:
+		logger.Default().Error("server failed, shutting down", "error", err)
+		exitCode = 1
+	}
+	stop()
+
+	// new requests keep being served while the load balancer notices /readyz
+	// failing, then the listeners close and the in-flight requests drain
+	checker.Drain()
+	time.Sleep(*shutdownDelay)
+
+	// the event streams only end when the broker is closed
+	broker.Close()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+	defer cancel()
+
+	servers := []*http.Server{srv}
+	if metricsSrv != nil {
+		servers = append(servers, metricsSrv)
+	}
+	for _, s := range servers {
+		if err := s.Shutdown(shutdownCtx); err != nil {
+			logger.Default().Warn("requests did not finish in time, closing their connections", "addr", s.Addr, "error", err)
+			s.Close()
+			exitCode = 1
+		}
 	}
+
+	// the workers finish the job they are running before the pool is closed
+	scheduler.Stop()
+	dispatcher.Stop()
+	purger.Stop()
+
+	if err := db.Close(); err != nil {
+		logger.Default().Error("closing the database failed", "error", err)
+		exitCode = 1
+	}
+
+	logger.Default().Info("server stopped")
+	os.Exit(exitCode)
+}
+
+// fatal logs a startup error and exits
+func fatal(msg string, keyvals ...interface{}) {
+	logger.Default().Error(msg, keyvals...)
+	os.Exit(1)
 }
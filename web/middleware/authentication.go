@@ -1,7 +1,6 @@
 package middleware
 
 import (
-	"context"
 	"encoding/base64"
 	"encoding/json"
 	"net/http"
@@ -9,6 +8,8 @@ import (
 	"time"
 
 	"github.com/cristiano-pacheco/go-api/core/auth"
+	"github.com/cristiano-pacheco/go-api/core/i18n"
+	"github.com/cristiano-pacheco/go-api/core/logger"
 	"github.com/cristiano-pacheco/go-api/web/common"
 	"github.com/gbrlsnchs/jwt/v3"
 	"github.com/gorilla/mux"
@@ -22,8 +23,8 @@ func CheckAuthentication(s *auth.Service) negroni.Handler {
 		// elimitar pacote authorization ou melhorar código
 		token := extractTokenFromHeaders(r)
 		if token == "" {
-			w.WriteHeader(http.StatusUnauthorized)
-			w.Write(common.FormatJSONError("Not Authorized"))
+			s.CountFailure(auth.FailureMissingToken)
+			common.WriteProblem(w, r, http.StatusUnauthorized, i18n.T(r.Context(), "not_authorized"))
 			return
 		}
 
@@ -36,48 +37,69 @@ func CheckAuthentication(s *auth.Service) negroni.Handler {
 
 		_, err := jwt.Verify([]byte(token), s.JWTHash, &pl, validatePayload)
 		if err != nil {
-			w.WriteHeader(http.StatusUnauthorized)
-			w.Write(common.FormatJSONError("Not Authorized"))
+			s.CountFailure(auth.FailureInvalidToken)
+			common.WriteProblem(w, r, http.StatusUnauthorized, i18n.T(r.Context(), "not_authorized"))
 			return
 		}
 
 		userId, err := getUserIdFromToken(token)
 		if err != nil || userId == 0 {
-			w.WriteHeader(http.StatusInternalServerError)
-			w.Write(common.FormatJSONError("Unable to parse the token data"))
+			common.WriteProblem(w, r, http.StatusInternalServerError, "Unable to parse the token data")
 			return
 		}
 
+		if locale, ok := i18n.Supported(pl.Locale); ok {
+			w.Header().Set("Content-Language", locale)
+			r = r.WithContext(i18n.WithLocale(r.Context(), locale))
+		}
+
 		routeName := mux.CurrentRoute(r).GetName()
 
 		if routeName != auth.UserME {
 			hasAccess, err := s.HasAccess(userId, routeName)
 			if err != nil {
-				w.WriteHeader(http.StatusInternalServerError)
-				w.Write(common.FormatJSONError(err.Error()))
+				common.WriteProblem(w, r, http.StatusInternalServerError, err.Error())
 				return
 			}
 
 			if !hasAccess {
-				w.WriteHeader(http.StatusUnauthorized)
-				w.Write(common.FormatJSONError("Not Authorized"))
+				s.CountFailure(auth.FailureForbidden)
+				common.WriteProblem(w, r, http.StatusForbidden, i18n.T(r.Context(), "forbidden"))
 				return
 			}
 		}
 
-		ctx := context.WithValue(r.Context(), "UserID", userId)
+		setLogUserID(r.Context(), int64(userId))
+		ctx := auth.ContextWithUserID(r.Context(), int64(userId))
+		ctx = logger.WithContext(ctx, logger.FromContext(ctx).With("user_id", userId))
 
 		next(w, r.WithContext(ctx))
 	})
 }
 
+// TokenFromQuery middleware accepts the token in the access_token query
+// parameter when the Authorization header is missing. Browsers can't set
+// headers on EventSource and WebSocket, so only the event stream uses it:
+// query strings end up in logs, browser history and Referer headers.
+func TokenFromQuery() negroni.Handler {
+	return negroni.HandlerFunc(func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		q := r.URL.Query()
+		if token := q.Get("access_token"); token != "" {
+			if r.Header.Get("Authorization") == "" {
+				r.Header.Set("Authorization", "Bearer "+token)
+			}
+			q.Del("access_token")
+			r.URL.RawQuery = q.Encode()
+		}
+
+		next(w, r)
+	})
+}
+
+// extractTokenFromHeaders reads the bearer token of the Authorization header
 func extractTokenFromHeaders(r *http.Request) string {
 	a := r.Header.Get("Authorization")
 
-	if a == "" {
-		return ""
-	}
-
 	parts := strings.Split(a, "Bearer")
 
 	if len(parts) == 2 {
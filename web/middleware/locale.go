@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/cristiano-pacheco/go-api/core/i18n"
+	"github.com/urfave/negroni"
+)
+
+// Localize middleware picks the locale of the messages from Accept-Language,
+// CheckAuthentication replaces it by the preference of the user if there is one
+func Localize() negroni.Handler {
+	return negroni.HandlerFunc(func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		locale := i18n.Match(r.Header.Get("Accept-Language"))
+		w.Header().Set("Content-Language", locale)
+		next(w, r.WithContext(i18n.WithLocale(r.Context(), locale)))
+	})
+}
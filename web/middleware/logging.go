@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/cristiano-pacheco/go-api/core/logger"
+	"github.com/cristiano-pacheco/go-api/web/common"
+	"github.com/gorilla/mux"
+	"github.com/urfave/negroni"
+)
+
+// request IDs sent by clients are kept when they are short and printable
+var requestIDRegex = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// probePaths are polled by the orchestrator every few seconds, their requests
+// are logged at the debug level so they do not flood the access log
+var probePaths = map[string]bool{"/healthz": true, "/readyz": true}
+
+// RequestID middleware keeps the X-Request-ID of the request or generates
+// one, and sends it back in the response
+func RequestID() negroni.Handler {
+	return negroni.HandlerFunc(func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		id := r.Header.Get(common.RequestIDHeader)
+		if !requestIDRegex.MatchString(id) {
+			id = newRequestID()
+			r.Header.Set(common.RequestIDHeader, id)
+		}
+
+		w.Header().Set(common.RequestIDHeader, id)
+		next(w, r)
+	})
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// requestLog holds what the access log learns after the request went down
+// the chain, CheckAuthentication fills the user
+type requestLog struct {
+	userID int64
+}
+
+type requestLogKey struct{}
+
+func setLogUserID(ctx context.Context, userID int64) {
+	if rl, ok := ctx.Value(requestLogKey{}).(*requestLog); ok {
+		rl.userID = userID
+	}
+}
+
+// Logger middleware puts a logger with the request ID in the request context
+// and logs every request with its route, user, status and latency. Health
+// probes are logged at the debug level.
+func Logger(l *logger.Logger) negroni.Handler {
+	return negroni.HandlerFunc(func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		start := time.Now()
+		rl := &requestLog{}
+		rlog := l.With("request_id", r.Header.Get(common.RequestIDHeader))
+
+		ctx := context.WithValue(r.Context(), requestLogKey{}, rl)
+		next(w, r.WithContext(logger.WithContext(ctx, rlog)))
+
+		status := http.StatusOK
+		if rw, ok := w.(negroni.ResponseWriter); ok && rw.Status() != 0 {
+			status = rw.Status()
+		}
+
+		route := ""
+		if cr := mux.CurrentRoute(r); cr != nil {
+			route = cr.GetName()
+		}
+
+		level := logger.Info
+		switch {
+		case probePaths[r.URL.Path]:
+			level = logger.Debug
+		case status >= http.StatusInternalServerError:
+			level = logger.Error
+		}
+
+		fields := []interface{}{
+			"method", r.Method,
+			"path", r.URL.Path,
+			"route", route,
+			"status", status,
+			"latency_ms", float64(time.Since(start).Microseconds()) / 1000,
+		}
+		if rl.userID != 0 {
+			fields = append(fields, "user_id", rl.userID)
+		}
+
+		rlog.Log(level, "request", fields...)
+	})
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cristiano-pacheco/go-api/core/i18n"
+	"github.com/cristiano-pacheco/go-api/web/common"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/urfave/negroni"
+)
+
+// Metrics middleware counts the requests by route, method and status and
+// records their latency by route and method. The route is the name of the
+// route, the permission it checks, or its path template when it has no name.
+func Metrics(reg prometheus.Registerer) negroni.Handler {
+	factory := promauto.With(reg)
+	requests := factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "goapi_http_requests_total",
+		Help: "Number of HTTP requests.",
+	}, []string{"route", "method", "status"})
+	latency := factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "goapi_http_request_duration_seconds",
+		Help:    "Latency of the HTTP requests.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	return negroni.HandlerFunc(func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		start := time.Now()
+		next(w, r)
+
+		status := http.StatusOK
+		if rw, ok := w.(negroni.ResponseWriter); ok && rw.Status() != 0 {
+			status = rw.Status()
+		}
+
+		route := routeLabel(r)
+		requests.WithLabelValues(route, r.Method, strconv.Itoa(status)).Inc()
+		latency.WithLabelValues(route, r.Method).Observe(time.Since(start).Seconds())
+	})
+}
+
+// routeLabel keeps the number of series bounded, the path itself is never used
+func routeLabel(r *http.Request) string {
+	cr := mux.CurrentRoute(r)
+	if cr == nil {
+		return "unmatched"
+	}
+
+	if name := cr.GetName(); name != "" {
+		return name
+	}
+
+	if tpl, err := cr.GetPathTemplate(); err == nil {
+		return tpl
+	}
+
+	return "unmatched"
+}
+
+// CheckToken middleware requires a fixed bearer token, for the endpoints read
+// by machines instead of users
+func CheckToken(token string) negroni.Handler {
+	return negroni.HandlerFunc(func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		given := extractTokenFromHeaders(r)
+		if given == "" || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			common.WriteProblem(w, r, http.StatusUnauthorized, i18n.T(r.Context(), "not_authorized"))
+			return
+		}
+
+		next(w, r)
+	})
+}
@@ -0,0 +1,245 @@
+package openapi
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cristiano-pacheco/go-api/core/patch"
+	"github.com/cristiano-pacheco/go-api/web/common"
+	"github.com/gorilla/mux"
+)
+
+// Version is the version of the OpenAPI specification of the documents
+const Version = "3.1.0"
+
+// media types of the request and response bodies
+const (
+	JSONType           = "application/json"
+	EventStreamType    = "text/event-stream"
+	bearerSecurityName = "bearer"
+)
+
+// Document is an OpenAPI document
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       *Info                            `json:"info"`
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components *Components                      `json:"components"`
+}
+
+// Info describes the API
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Components holds the schemas of the named types and the security schemes
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes"`
+}
+
+// SecurityScheme is a way to authenticate requests
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// Operation is a method of a path. Permission is the name of the permission
+// the route requires, it is the name of the route.
+type Operation struct {
+	OperationID string                `json:"operationId,omitempty"`
+	Summary     string                `json:"summary,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []*Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	Permission  string                `json:"x-permission,omitempty"`
+}
+
+// Parameter is a path, query or header parameter
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody is the body of a request by media type
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response is a response of an operation by media type
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType is the schema of a body
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Route documents a route. Request and Response are values of the types of
+// the bodies, a nil Response means the response has no body. Patch routes set
+// Patch to the type of a merge patch, JSON Patch is documented as well.
+type Route struct {
+	Summary      string
+	Tag          string
+	Query        []*Parameter
+	Request      interface{}
+	Patch        interface{}
+	Response     interface{}
+	ResponseType string
+	Status       int
+}
+
+// Undocumented is returned by Build with the routes that have no Route
+type Undocumented []string
+
+func (u Undocumented) Error() string {
+	return "routes missing from the OpenAPI document: " + strings.Join(u, ", ")
+}
+
+// Key returns the key of a route in the routes given to Build
+func Key(method string, path string) string {
+	return method + " " + path
+}
+
+var pathParamRegex = regexp.MustCompile(`{([^}:]+)(:[^}]+)?}`)
+
+// Build documents every route of a router with the Route of its key. The named
+// routes require a token and their name is the permission they check. Routes
+// without a Route are added with what the router knows about them and
+// returned as an Undocumented error.
+func Build(info *Info, r *mux.Router, routes map[string]*Route) (*Document, error) {
+	g := NewGenerator()
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   make(map[string]map[string]*Operation),
+		Components: &Components{
+			Schemas: g.Schemas,
+			SecuritySchemes: map[string]*SecurityScheme{
+				bearerSecurityName: {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			},
+		},
+	}
+
+	var undocumented Undocumented
+	err := r.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+
+		for _, method := range methods {
+			if method == http.MethodOptions {
+				continue
+			}
+
+			doc.addOperation(g, method, path, route.GetName(), routes[Key(method, path)])
+			if routes[Key(method, path)] == nil {
+				undocumented = append(undocumented, Key(method, path))
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(undocumented) > 0 {
+		sort.Strings(undocumented)
+		return doc, undocumented
+	}
+
+	return doc, nil
+}
+
+func (doc *Document) addOperation(g *Generator, method string, path string, name string, route *Route) {
+	if route == nil {
+		route = &Route{}
+	}
+
+	op := &Operation{
+		OperationID: name,
+		Summary:     route.Summary,
+		Responses:   make(map[string]*Response),
+		Permission:  name,
+	}
+	if route.Tag != "" {
+		op.Tags = []string{route.Tag}
+	}
+	if name != "" {
+		op.Security = []map[string][]string{{bearerSecurityName: {}}}
+	}
+
+	for _, m := range pathParamRegex.FindAllStringSubmatch(path, -1) {
+		op.Parameters = append(op.Parameters, &Parameter{
+			Name:     m[1],
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "integer", Format: "int64"},
+		})
+	}
+	op.Parameters = append(op.Parameters, route.Query...)
+
+	if route.Request != nil {
+		op.RequestBody = &RequestBody{Required: true, Content: map[string]*MediaType{
+			JSONType: {Schema: g.Schema(route.Request)},
+		}}
+	}
+	if route.Patch != nil {
+		op.RequestBody = &RequestBody{Required: true, Content: map[string]*MediaType{
+			patch.MergePatchType: {Schema: g.Schema(route.Patch)},
+			patch.JSONPatchType:  {Schema: g.Schema([]*patch.Operation{})},
+		}}
+	}
+
+	status := route.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	res := &Response{Description: http.StatusText(status)}
+	if route.Response != nil {
+		mediaType := route.ResponseType
+		if mediaType == "" {
+			mediaType = JSONType
+		}
+		res.Content = map[string]*MediaType{mediaType: {Schema: g.Schema(route.Response)}}
+	}
+	op.Responses[strconv.Itoa(status)] = res
+	op.Responses["default"] = &Response{
+		Description: "Error",
+		Content:     map[string]*MediaType{common.ProblemContentType: {Schema: g.Schema(&common.Problem{})}},
+	}
+
+	// the mux path template is already an OpenAPI path once the patterns of
+	// the variables are dropped
+	path = pathParamRegex.ReplaceAllString(path, "{$1}")
+	if doc.Paths[path] == nil {
+		doc.Paths[path] = make(map[string]*Operation)
+	}
+	doc.Paths[path][strings.ToLower(method)] = op
+}
+
+// QueryParam returns an optional query parameter
+func QueryParam(name string, typ string, description string) *Parameter {
+	return &Parameter{Name: name, In: "query", Description: description, Schema: &Schema{Type: typ}}
+}